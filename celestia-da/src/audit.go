@@ -0,0 +1,261 @@
+package celestiada
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Audited operations. Submissions are recorded when a batch is accepted or
+// rejected, not when it is published. Metadata removed by DeleteBatchMetadata
+// is recorded as a delete and metadata removed by retention as an evict,
+// metadata written by ImportMetadata as an import, and each rewrite of the
+// backing store as a compact with batch number 0.
+const (
+	AuditOpSubmit   = "submit"
+	AuditOpRetrieve = "retrieve"
+	AuditOpDelete   = "delete"
+	AuditOpEvict    = "evict"
+	AuditOpImport   = "import"
+	AuditOpCompact  = "compact"
+)
+
+type auditCallerKey struct{}
+
+// WithAuditCallerID returns a context that attributes audited operations made
+// with it to callerID.
+func WithAuditCallerID(ctx context.Context, callerID string) context.Context {
+	return context.WithValue(ctx, auditCallerKey{}, callerID)
+}
+
+func auditCallerID(ctx context.Context) string {
+	callerID, _ := ctx.Value(auditCallerKey{}).(string)
+	return callerID
+}
+
+// AuditEntry is one line of the audit log. PrevHash is the hex SHA-256 of the
+// previous line as written, or empty for the first line.
+type AuditEntry struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Operation   string    `json:"operation"`
+	BatchNumber uint64    `json:"batchNumber"`
+	CallerID    string    `json:"callerId,omitempty"`
+	Result      string    `json:"result"`
+	PrevHash    string    `json:"prevHash"`
+}
+
+// auditLog appends hash-chained JSON lines to Config.AuditLogPath. Each write
+// is synced before the operation returns.
+type auditLog struct {
+	mu       sync.Mutex
+	file     *os.File
+	prevHash string
+}
+
+// openAuditLog opens the audit log at path, creating it if needed, and
+// resumes the hash chain from its last line. A last line without a newline
+// was cut short by a crash while it was written, before the operation it
+// records returned, so it is truncated away first.
+func openAuditLog(path string) (*auditLog, error) {
+	if err := truncatePartialAuditLine(path); err != nil {
+		return nil, err
+	}
+	prevHash, err := lastAuditHash(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &auditLog{file: file, prevHash: prevHash}, nil
+}
+
+// truncatePartialAuditLine cuts the audit log at path back to its last
+// newline.
+func truncatePartialAuditLine(path string) error {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat audit log: %w", err)
+	}
+
+	// Scan backwards for the last newline, a block at a time.
+	end := info.Size()
+	buf := make([]byte, 4096)
+	for offset := end; offset > 0; {
+		n := int64(len(buf))
+		if offset < n {
+			n = offset
+		}
+		offset -= n
+		if _, err := file.ReadAt(buf[:n], offset); err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read audit log: %w", err)
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			end = offset + int64(i) + 1
+			break
+		}
+		if offset == 0 {
+			end = 0
+		}
+	}
+	if end == info.Size() {
+		return nil
+	}
+
+	if err := file.Truncate(end); err != nil {
+		return fmt.Errorf("failed to truncate partial audit log line: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync audit log: %w", err)
+	}
+	return nil
+}
+
+// lastAuditHash returns the hash of the last line of the audit log at path.
+// Blank lines are skipped, as in VerifyAuditLog.
+func lastAuditHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var last []byte
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) > 0 {
+			last = append(last[:0], scanner.Bytes()...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read audit log: %w", err)
+	}
+	if last == nil {
+		return "", nil
+	}
+	return auditLineHash(last), nil
+}
+
+func auditLineHash(line []byte) string {
+	sum := sha256.Sum256(line)
+	return hex.EncodeToString(sum[:])
+}
+
+func (a *auditLog) record(entry AuditEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entry.PrevHash = a.prevHash
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	if _, err := a.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := a.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync audit log: %w", err)
+	}
+	a.prevHash = auditLineHash(line)
+	return nil
+}
+
+func (a *auditLog) close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.file.Close()
+}
+
+// audit records an operation if Config.AuditLogPath is set. A failure to
+// write the log is logged but does not fail the operation being audited.
+func (c *CDKIntegration) audit(ctx context.Context, operation string, batchNumber uint64, err error) {
+	if c.auditLog == nil {
+		return
+	}
+
+	result := "ok"
+	if err != nil {
+		result = err.Error()
+	}
+
+	auditErr := c.auditLog.record(AuditEntry{
+		Timestamp:   time.Now().UTC(),
+		Operation:   operation,
+		BatchNumber: batchNumber,
+		CallerID:    auditCallerID(ctx),
+		Result:      result,
+	})
+	if auditErr != nil {
+		c.logger().Error("audit log write failed", "operation", operation, "batch", batchNumber, "error", auditErr)
+	}
+}
+
+// closeAuditLog closes the audit log unless it is shared with a parent
+// integration.
+func (c *CDKIntegration) closeAuditLog() error {
+	if c.auditLog == nil || c.parent != nil {
+		return nil
+	}
+	if err := c.auditLog.close(); err != nil {
+		return fmt.Errorf("failed to close audit log: %w", err)
+	}
+	return nil
+}
+
+// VerifyAuditLog checks the hash chain of the audit log at path. It returns
+// valid false and the 1-based number of the first line whose PrevHash does
+// not match the line before it, or that cannot be parsed. Blank lines are not
+// part of the chain and are skipped. Editing or deleting any line but the
+// last breaks the chain at the line after it; changes to the last line and
+// truncation of the log cannot be detected from the log alone.
+func VerifyAuditLog(path string) (valid bool, firstTamperedLine int, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	prevHash := ""
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return false, line, nil
+		}
+		if entry.PrevHash != prevHash {
+			return false, line, nil
+		}
+		prevHash = auditLineHash(scanner.Bytes())
+	}
+	if err := scanner.Err(); err != nil {
+		return false, 0, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return true, 0, nil
+}
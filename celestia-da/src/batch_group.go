@@ -0,0 +1,236 @@
+package celestiada
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SubmitBatchGroup publishes several batches in one Blob.Submit call so they
+// are all included at the same Celestia height. The group bypasses the batch
+// queue but, like queued batches, waits while processing is suspended, is
+// published under the integration's lifetime rather than ctx, and is waited
+// for by Close. Every batch is validated, and checked against its MaxFeeUTIA
+// if set, before anything is submitted; a batch that fails either check
+// rejects the whole group. The group is one transaction, so if any batch sets
+// UseHighPriority the whole group pays the priority gas price, and fee caps
+// are checked at that price; if any batch sets a fee cap, the group is
+// priced as SubmitBatchWithFeeLimit prices a batch. Batches whose Deadline has passed by the time
+// the group is submitted fail with ErrDeadlineExceeded and are left out.
+// Every batch in the group is audited with the group's acceptance result.
+//
+// Published and failed batches are recorded like queued ones: in Stats and
+// the metrics recorder, in the failures ResubmitFailed retries and
+// WatchBatchFailures reports, and through Config.OnError. Failed attempts are
+// retried as in SubmitBatch, Config.DefaultConfirmations is waited for, and a
+// batch that was included is never submitted again. Groups are not supported
+// in quorum mode and are not published to the fallback DA layer.
+func (c *CDKIntegration) SubmitBatchGroup(ctx context.Context, batches []*BatchData) (<-chan []PublishResult, error) {
+	resultChan, err := c.submitBatchGroup(ctx, batches)
+	for _, batch := range batches {
+		c.audit(ctx, AuditOpSubmit, batch.Number, err)
+	}
+	return resultChan, err
+}
+
+func (c *CDKIntegration) submitBatchGroup(ctx context.Context, batches []*BatchData) (<-chan []PublishResult, error) {
+	if len(batches) == 0 {
+		return nil, fmt.Errorf("batch group is empty")
+	}
+	if c.quorum != nil {
+		return nil, fmt.Errorf("batch groups are not supported in quorum mode")
+	}
+	if c.ctx.Err() != nil || c.stopping.Load() {
+		return nil, fmt.Errorf("CDK integration is shutting down")
+	}
+
+	highPriority, capped := false, false
+	for _, batch := range batches {
+		highPriority = highPriority || batch.UseHighPriority
+		capped = capped || batch.MaxFeeUTIA > 0
+	}
+	gasPrice := c.publisher.gasPrice(highPriority)
+	if capped {
+		var err error
+		if gasPrice, err = c.publisher.feeGasPrice(ctx, highPriority); err != nil {
+			return nil, err
+		}
+	}
+
+	payloads := make([][]byte, len(batches))
+	for i, batch := range batches {
+		if err := c.validateBatch(batch); err != nil {
+			return nil, fmt.Errorf("batch %d: %w", batch.Number, err)
+		}
+		payload, err := c.batchPayload(batch)
+		if err != nil {
+			return nil, err
+		}
+		payloads[i] = payload.Data
+		if batch.MaxFeeUTIA > 0 {
+			if err := c.publisher.checkFeeLimit(payloads[i], batch.MaxFeeUTIA, gasPrice); err != nil {
+				return nil, fmt.Errorf("batch %d: %w", batch.Number, err)
+			}
+		}
+	}
+
+	resultChan := make(chan []PublishResult, 1)
+	started := c.goTracked(func() {
+		c.processingMu.RLock()
+		defer c.processingMu.RUnlock()
+
+		resultChan <- c.processBatchGroup(batches, payloads, gasPrice)
+	})
+	if !started {
+		return nil, fmt.Errorf("CDK integration is shutting down")
+	}
+	return resultChan, nil
+}
+
+// groupMember tracks one batch of a group across attempts. pending is set
+// once the batch has been included but not yet verified, so later attempts
+// only resume its verification.
+type groupMember struct {
+	index    int
+	batch    *BatchData
+	payload  []byte
+	pending  unconfirmedError
+	refID    string
+	err      error
+	attempts int
+	done     bool
+}
+
+// processBatchGroup publishes the payloads of the batches of a group that
+// have not passed their deadline and records every result the way
+// processBatch does.
+func (c *CDKIntegration) processBatchGroup(batches []*BatchData, payloads [][]byte, gasPrice float64) []PublishResult {
+	ctx := c.ctx
+	if timeout := time.Duration(c.batchTimeout.Load()); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	results := make([]PublishResult, len(batches))
+	var members []*groupMember
+	now := time.Now()
+	for i, batch := range batches {
+		if !batch.Deadline.IsZero() && now.After(batch.Deadline) {
+			results[i] = PublishResult{
+				Success: false,
+				Error:   fmt.Errorf("batch %d: %w", batch.Number, ErrDeadlineExceeded),
+			}
+			continue
+		}
+		members = append(members, &groupMember{index: i, batch: batch, payload: payloads[i]})
+	}
+	if len(members) == 0 {
+		return results
+	}
+
+	start := time.Now()
+	c.submitGroupWithRetry(ctx, members, gasPrice)
+	duration := time.Since(start)
+
+	for _, m := range members {
+		var result PublishResult
+		if m.done {
+			result = c.recordPublished(m.batch, m.payload, m.refID, gasPrice)
+			if result.Success {
+				c.notifyMetadata(result.Metadata)
+			}
+			if m.batch.UseHighPriority {
+				c.highPriority.Add(1)
+			}
+		} else {
+			err := fmt.Errorf("failed to publish batch %d after %d attempts: %w", m.batch.Number, m.attempts, m.err)
+			c.notifyFailure(m.batch, err, m.attempts)
+			result = PublishResult{
+				Success: false,
+				Error:   err,
+			}
+		}
+
+		c.recordResult(result, duration)
+		c.recordMetrics(duration, result.Success, m.batch.Number)
+		if result.Success || permanentFailure(result.Error) {
+			c.failedBatches.Delete(m.batch.Number)
+		} else {
+			c.failedBatches.Store(m.batch.Number, m.batch)
+		}
+		results[m.index] = result
+	}
+	c.maybeEvict()
+
+	return results
+}
+
+// submitGroupWithRetry submits the members that have not been included in
+// one Blob.Submit call and resumes verification of those that have, retrying
+// like submitWithRetry until every member is done, fails permanently, or
+// Config.MaxRetries is reached.
+func (c *CDKIntegration) submitGroupWithRetry(ctx context.Context, members []*groupMember, gasPrice float64) {
+	confirmations := c.config.DefaultConfirmations
+	for retry := 0; ; retry++ {
+		var unsent []*groupMember
+		var payloads [][]byte
+		for _, m := range members {
+			switch {
+			case m.done || (m.err != nil && permanentFailure(m.err)):
+			case m.pending.refID != "":
+				m.attempts++
+				m.err = c.publisher.confirm(ctx, m.pending, confirmations)
+				c.finishGroupAttempt(m, m.pending.refID, retry)
+			default:
+				unsent = append(unsent, m)
+				payloads = append(payloads, m.payload)
+			}
+		}
+
+		if len(payloads) > 0 {
+			submitted := c.publisher.submitBlobs(ctx, payloads, gasPrice)
+			for j, m := range unsent {
+				m.attempts++
+				m.err = submitted[j].Error
+				if m.err == nil && confirmations > 0 {
+					m.err = c.publisher.confirm(ctx, unconfirmedError{refID: submitted[j].RefID, sampled: true}, confirmations)
+				}
+				c.finishGroupAttempt(m, submitted[j].RefID, retry)
+			}
+		}
+
+		remaining := false
+		for _, m := range members {
+			remaining = remaining || (!m.done && !permanentFailure(m.err))
+		}
+		if !remaining || retry >= c.config.MaxRetries {
+			return
+		}
+
+		select {
+		case <-time.After(c.config.RetryDelay << retry):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// finishGroupAttempt records the outcome of one attempt for m, whose blob is
+// at refID if it was included.
+func (c *CDKIntegration) finishGroupAttempt(m *groupMember, refID string, retry int) {
+	if m.err == nil {
+		m.refID = refID
+		m.done = true
+		return
+	}
+
+	var unconfirmed unconfirmedError
+	if errors.As(m.err, &unconfirmed) {
+		m.pending = unconfirmed
+	}
+	if c.config.OnError != nil {
+		c.config.OnError(m.batch.Number, m.err, retry)
+	}
+}
@@ -0,0 +1,78 @@
+package celestiada
+
+import "fmt"
+
+// NewChildContext creates an integration that shares this one's Publisher,
+// and with it the connection pool, token cooldowns and fallback DA layer,
+// but keeps its own metadata and its own batch queue and workers. It is
+// meant for running separate metadata domains, such as mainnet and testnet
+// batches, over one Celestia connection. The child uses the parent's config,
+// with its own copy of a retention policy that keeps state across eviction
+// passes, such as MaxCountPolicy; other policies are shared with the child
+// and must be safe for concurrent use.
+// If the parent's store is a FileMetadataStore, whether opened by
+// LoadOrCreate or passed in Config.MetadataStore, the child opens its own
+// file store at the same path with "." and storeSuffix appended; if the
+// parent uses the default in-memory store, so does the child. Any other
+// caller-provided store cannot be derived from and is an error, since the
+// child would otherwise silently lose its metadata on exit. The child writes
+// to the parent's audit log, if any. Closing the child leaves the shared
+// publisher and audit log open, so children must be closed before their
+// parent.
+func (c *CDKIntegration) NewChildContext(storeSuffix string) (*CDKIntegration, error) {
+	if storeSuffix == "" {
+		return nil, fmt.Errorf("store suffix is empty")
+	}
+	if c.stopping.Load() {
+		return nil, fmt.Errorf("CDK integration is shutting down")
+	}
+
+	config := c.config
+	config.NamespaceID = c.publisher.Namespace()
+	config.AuditLogPath = ""
+	if pass, ok := config.RetentionPolicy.(retentionPass); ok {
+		config.RetentionPolicy = pass.clone()
+	}
+	parentFile := c.ownedStore
+	if parentFile == nil {
+		parentFile, _ = c.config.MetadataStore.(*FileMetadataStore)
+	}
+
+	var owned *FileMetadataStore
+	switch {
+	case parentFile != nil:
+		store, err := NewFileMetadataStore(parentFile.path + "." + storeSuffix)
+		if err != nil {
+			return nil, err
+		}
+		owned = store
+		config.MetadataStore = store
+	case c.config.MetadataStore == nil:
+		config.MetadataStore = NewMemoryMetadataStore()
+	default:
+		return nil, fmt.Errorf("cannot derive a child metadata store from a %T", c.config.MetadataStore)
+	}
+
+	child, err := newCDKIntegration(config, c.publisher)
+	if err != nil {
+		if owned != nil {
+			owned.Close()
+		}
+		return nil, err
+	}
+	child.ownedStore = owned
+	child.quorum = c.quorum
+	child.parent = c
+	child.auditLog = c.auditLog
+
+	return child, nil
+}
+
+// closePublisher closes the publisher unless it is borrowed from a parent
+// integration.
+func (c *CDKIntegration) closePublisher() error {
+	if c.parent != nil {
+		return nil
+	}
+	return c.publisher.Close()
+}
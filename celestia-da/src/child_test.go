@@ -0,0 +1,52 @@
+package celestiada
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNewChildContextStores(t *testing.T) {
+	t.Run("file store", func(t *testing.T) {
+		store, err := NewFileMetadataStore(filepath.Join(t.TempDir(), "metadata.log"))
+		if err != nil {
+			t.Fatalf("NewFileMetadataStore: %v", err)
+		}
+		defer store.Close()
+		parent := newTestIntegration(t, Config{MetadataStore: store}, NewFakePublisher())
+
+		child, err := parent.NewChildContext("testnet")
+		if err != nil {
+			t.Fatalf("NewChildContext: %v", err)
+		}
+		defer child.Close()
+		if child.ownedStore == nil || child.ownedStore.path != store.path+".testnet" {
+			t.Fatalf("child store was not derived from %s", store.path)
+		}
+	})
+
+	t.Run("caller-provided store", func(t *testing.T) {
+		parent := newTestIntegration(t, Config{MetadataStore: NewMemoryMetadataStore()}, NewFakePublisher())
+
+		if _, err := parent.NewChildContext("testnet"); err == nil {
+			t.Fatal("NewChildContext accepted a store it cannot derive from")
+		}
+	})
+}
+
+func TestNewChildContextCopiesStatefulRetentionPolicy(t *testing.T) {
+	policy := MaxCountPolicy(10)
+	parent := newTestIntegration(t, Config{RetentionPolicy: policy}, NewFakePublisher())
+
+	child, err := parent.NewChildContext("testnet")
+	if err != nil {
+		t.Fatalf("NewChildContext: %v", err)
+	}
+	defer child.Close()
+
+	if child.config.RetentionPolicy == policy {
+		t.Fatal("child shares the parent's MaxCountPolicy")
+	}
+	if got := child.config.RetentionPolicy.(*maxCountPolicy).maxCount; got != 10 {
+		t.Fatalf("child policy keeps %d batches, want 10", got)
+	}
+}
@@ -0,0 +1,71 @@
+package celestiada
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	client "github.com/celestiaorg/celestia-openrpc/types/client"
+)
+
+const defaultTokenCooldown = 30 * time.Second
+
+// pooledClient is a connection authenticated with one of Config.AuthTokens.
+// A client whose token was rate limited is skipped until cooldownUntil.
+type pooledClient struct {
+	client        *client.Client
+	cooldownUntil atomic.Int64
+}
+
+func newClientPool(endpoint string, tokens []string) ([]*pooledClient, error) {
+	pool := make([]*pooledClient, 0, len(tokens))
+	for i, token := range tokens {
+		c, err := client.NewClient(context.Background(), endpoint, token)
+		if err != nil {
+			for _, pc := range pool {
+				pc.client.Close()
+			}
+			return nil, fmt.Errorf("failed to create Celestia client %d: %w", i, err)
+		}
+		pool = append(pool, &pooledClient{client: c})
+	}
+	return pool, nil
+}
+
+func (pc *pooledClient) coolDown(d time.Duration) {
+	pc.cooldownUntil.Store(time.Now().Add(d).UnixNano())
+}
+
+func (pc *pooledClient) available(now time.Time) bool {
+	return now.UnixNano() >= pc.cooldownUntil.Load()
+}
+
+// nextClient picks the next pooled client round-robin, skipping clients that
+// are cooling down. If every client is cooling down the next one is used
+// anyway rather than failing the submission outright.
+func (p *Publisher) nextClient() *pooledClient {
+	now := time.Now()
+	start := p.poolNext.Add(1) - 1
+
+	for i := uint64(0); i < uint64(len(p.pool)); i++ {
+		pc := p.pool[(start+i)%uint64(len(p.pool))]
+		if pc.available(now) {
+			return pc
+		}
+	}
+	return p.pool[start%uint64(len(p.pool))]
+}
+
+func (p *Publisher) tokenCooldown() time.Duration {
+	if p.config.TokenCooldownDuration > 0 {
+		return p.config.TokenCooldownDuration
+	}
+	return defaultTokenCooldown
+}
+
+func isRateLimited(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "429") || strings.Contains(strings.ToLower(msg), "too many requests")
+}
@@ -0,0 +1,54 @@
+package celestiada
+
+import "context"
+
+// SubmitBatchCoalesced queues a batch unless a coalesced submission of the
+// same batch number is still in flight, in which case the caller shares that
+// submission: every caller gets its own channel carrying the same result.
+// The data of a merged call is ignored. Only calls through this method are
+// merged; SubmitBatch always queues.
+func (c *CDKIntegration) SubmitBatchCoalesced(ctx context.Context, batchNumber uint64, data []byte, stateRoot string, txCount int) <-chan PublishResult {
+	resultChan := make(chan PublishResult, 1)
+
+	c.coalesceMu.Lock()
+	if subscribers, ok := c.coalesced[batchNumber]; ok {
+		c.coalesced[batchNumber] = append(subscribers, resultChan)
+		c.coalesceMu.Unlock()
+		return resultChan
+	}
+	c.coalesced[batchNumber] = []chan PublishResult{resultChan}
+	c.coalesceMu.Unlock()
+
+	batch := &BatchData{
+		Number:     batchNumber,
+		Data:       data,
+		StateRoot:  stateRoot,
+		TxCount:    txCount,
+		ResultChan: make(chan PublishResult, 1),
+	}
+
+	if err := c.tryEnqueue(ctx, batch, -1); err != nil {
+		c.deliverCoalesced(batchNumber, PublishResult{
+			Success: false,
+			Error:   err,
+		})
+		return resultChan
+	}
+
+	go func() {
+		c.deliverCoalesced(batchNumber, <-batch.ResultChan)
+	}()
+
+	return resultChan
+}
+
+func (c *CDKIntegration) deliverCoalesced(batchNumber uint64, result PublishResult) {
+	c.coalesceMu.Lock()
+	subscribers := c.coalesced[batchNumber]
+	delete(c.coalesced, batchNumber)
+	c.coalesceMu.Unlock()
+
+	for _, subscriber := range subscribers {
+		subscriber <- result
+	}
+}
@@ -0,0 +1,86 @@
+package celestiada
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// MetadataCompactor is implemented by metadata stores whose backing storage
+// can be rewritten in compacted form. Compact must read, rewrite and swap in
+// the storage atomically with respect to the store's own writes, so that no
+// Store or Delete made while it runs is lost or undone.
+type MetadataCompactor interface {
+	Compact() error
+}
+
+func (c *CDKIntegration) persistentStore() MetadataStore {
+	if c.metadataCache != nil {
+		return c.metadataCache.backing
+	}
+	return c.metadataStore
+}
+
+// CompactMetadataStore stops batch processing, has the backing store rewrite
+// itself, and lets processing continue. Processing the caller suspended with
+// SuspendProcessing stays suspended. FileMetadataStore writes its
+// entries sorted by batch number and migrated to the current schema version.
+// Stores that do not implement MetadataCompactor are left as they are.
+func (c *CDKIntegration) CompactMetadataStore(ctx context.Context) error {
+	compactor, ok := c.persistentStore().(MetadataCompactor)
+	if !ok {
+		return nil
+	}
+
+	c.holdProcessing()
+	defer c.releaseProcessing()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := compactor.Compact()
+	c.audit(ctx, AuditOpCompact, 0, err)
+	if err != nil {
+		return fmt.Errorf("failed to compact metadata store: %w", err)
+	}
+
+	c.compactMu.Lock()
+	c.lastCompacted = time.Now()
+	c.compactMu.Unlock()
+	c.sinceCompact.Store(0)
+
+	return nil
+}
+
+func (c *CDKIntegration) LastCompacted() time.Time {
+	c.compactMu.Lock()
+	defer c.compactMu.Unlock()
+
+	return c.lastCompacted
+}
+
+// maybeAutoCompact starts a background compaction once
+// Config.AutoCompactAfterBatches writes have happened since the last one. It
+// runs in its own goroutine because compaction waits for workers to go idle;
+// Close waits for it so the store is not closed mid-rewrite.
+func (c *CDKIntegration) maybeAutoCompact() {
+	threshold := c.config.AutoCompactAfterBatches
+	if threshold <= 0 || c.sinceCompact.Add(1) < int64(threshold) {
+		return
+	}
+	if !c.compacting.CompareAndSwap(false, true) {
+		return
+	}
+
+	started := c.goTracked(func() {
+		defer c.compacting.Store(false)
+
+		if err := c.CompactMetadataStore(c.ctx); err != nil {
+			c.logger().Error("auto-compaction of metadata store failed", "error", err)
+		}
+	})
+	if !started {
+		c.compacting.Store(false)
+	}
+}
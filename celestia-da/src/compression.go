@@ -0,0 +1,131 @@
+package celestiada
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+)
+
+const (
+	CompressionGzip = "gzip"
+	CompressionZlib = "zlib"
+)
+
+func validCompression(codec string) bool {
+	switch codec {
+	case "", CompressionGzip, CompressionZlib:
+		return true
+	}
+	return false
+}
+
+func compressData(data []byte, codec string) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+
+	switch codec {
+	case CompressionGzip:
+		w = gzip.NewWriter(&buf)
+	case CompressionZlib:
+		w = zlib.NewWriter(&buf)
+	default:
+		return nil, fmt.Errorf("unsupported compression codec %q", codec)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to compress data: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress data: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func decompressData(data []byte, codec string) ([]byte, error) {
+	var r io.ReadCloser
+	var err error
+
+	switch codec {
+	case CompressionGzip:
+		r, err = gzip.NewReader(bytes.NewReader(data))
+	case CompressionZlib:
+		r, err = zlib.NewReader(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("unsupported compression codec %q", codec)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress data: %w", err)
+	}
+	defer r.Close()
+
+	decompressed, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress data: %w", err)
+	}
+	return decompressed, nil
+}
+
+// CompressBatchData compresses data with the given codec, CompressionGzip or
+// CompressionZlib, the same way batches are compressed when
+// Config.Compression is set, so callers can choose per batch what to
+// compress without setting it. A batch compressed this way is published as
+// given, so readers must decompress retrieved data themselves.
+func (c *CDKIntegration) CompressBatchData(data []byte, codec string) ([]byte, error) {
+	return compressData(data, codec)
+}
+
+// batchPayload returns the batch to submit in place of batch: batch itself,
+// or, when Config.Compression is set, a copy whose data is compressed.
+func (c *CDKIntegration) batchPayload(batch *BatchData) (*BatchData, error) {
+	if c.config.Compression == "" {
+		return batch, nil
+	}
+
+	compressed, err := compressData(batch.Data, c.config.Compression)
+	if err != nil {
+		return nil, fmt.Errorf("batch %d: %w", batch.Number, err)
+	}
+	payload := *batch
+	payload.Data = compressed
+	return &payload, nil
+}
+
+// recordCompression notes in metadata that the batch was published as
+// payload compressed with Config.Compression.
+func (c *CDKIntegration) recordCompression(metadata *BatchMetadata, payload []byte) {
+	if c.config.Compression == "" {
+		return
+	}
+	metadata.Compression = c.config.Compression
+	metadata.CompressedSize = uint64(len(payload))
+}
+
+// decompressBatchData undoes the compression metadata records for data
+// retrieved for it.
+func decompressBatchData(metadata *BatchMetadata, data []byte) ([]byte, error) {
+	if metadata.Compression == "" {
+		return data, nil
+	}
+	decompressed, err := decompressData(data, metadata.Compression)
+	if err != nil {
+		return nil, fmt.Errorf("batch %d: %w", metadata.BatchNumber, err)
+	}
+	return decompressed, nil
+}
+
+// decodeBlobData decompresses a blob found in the namespace when
+// Config.Compression is set. Blobs that do not decompress, such as those
+// published before compression was enabled, are returned as they are;
+// compressed reports which case applied.
+func (c *CDKIntegration) decodeBlobData(data []byte) (decoded []byte, compressed bool) {
+	if c.config.Compression == "" {
+		return data, false
+	}
+	decompressed, err := decompressData(data, c.config.Compression)
+	if err != nil {
+		return data, false
+	}
+	return decompressed, true
+}
@@ -0,0 +1,162 @@
+package celestiada
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+const (
+	maskedSecret = "***"
+	valueSet     = "<set>"
+	valueNotSet  = "<not set>"
+)
+
+// ConfigSnapshot is the active configuration in a form that is safe to share
+// in debug reports. Secrets are masked, and fields that hold functions or
+// other non-serializable values report whether, or with which type, they are
+// set.
+type ConfigSnapshot struct {
+	Endpoint                     string        `json:"endpoint"`
+	NamespaceID                  string        `json:"namespaceId"`
+	AuthToken                    string        `json:"authToken"`
+	GasPrice                     float64       `json:"gasPrice"`
+	MaxBlobSize                  uint64        `json:"maxBlobSize"`
+	SubmitTimeout                time.Duration `json:"submitTimeout"`
+	StrictOrdering               bool          `json:"strictOrdering"`
+	WorkerCount                  int           `json:"workerCount"`
+	MetadataStore                string        `json:"metadataStore"`
+	MetadataCacheSize            int           `json:"metadataCacheSize"`
+	TailBufferSize               int           `json:"tailBufferSize"`
+	AutoCompactAfterBatches      int           `json:"autoCompactAfterBatches"`
+	DefaultConfirmations         uint64        `json:"defaultConfirmations"`
+	AuthTokens                   []string      `json:"authTokens"`
+	TokenCooldownDuration        time.Duration `json:"tokenCooldownDuration"`
+	MinServerVersion             string        `json:"minServerVersion"`
+	StrictVersionCheck           bool          `json:"strictVersionCheck"`
+	MaxRetries                   int           `json:"maxRetries"`
+	RetryDelay                   time.Duration `json:"retryDelay"`
+	OnError                      string        `json:"onError"`
+	SubscribeReconnectDelay      time.Duration `json:"subscribeReconnectDelay"`
+	MaxSubscribeReconnects       int           `json:"maxSubscribeReconnects"`
+	BatchQueueTimeout            time.Duration `json:"batchQueueTimeout"`
+	MaxSnapshotBatches           int           `json:"maxSnapshotBatches"`
+	HeartbeatInterval            time.Duration `json:"heartbeatInterval"`
+	RetrieveTimeout              time.Duration `json:"retrieveTimeout"`
+	RateWindowSeconds            int           `json:"rateWindowSeconds"`
+	VerificationMode             string        `json:"verificationMode"`
+	DASSamplingTimeout           time.Duration `json:"dasSamplingTimeout"`
+	UTIAUSDPriceFeed             string        `json:"utiaUsdPriceFeed"`
+	HeightTargetingTimeout       time.Duration `json:"heightTargetingTimeout"`
+	RetentionPolicy              string        `json:"retentionPolicy"`
+	EvictionCheckInterval        int           `json:"evictionCheckInterval"`
+	Logger                       string        `json:"logger"`
+	AllowFullBlockFetch          bool          `json:"allowFullBlockFetch"`
+	UnhealthyAlertRepeatInterval time.Duration `json:"unhealthyAlertRepeatInterval"`
+	RPCTracer                    string        `json:"rpcTracer"`
+	HeightCacheTTL               time.Duration `json:"heightCacheTtl"`
+	PriorityGasMultiplier        float64       `json:"priorityGasMultiplier"`
+	AuditLogPath                 string        `json:"auditLogPath"`
+	MaxSquareSize                uint64        `json:"maxSquareSize"`
+	Compression                  string        `json:"compression"`
+}
+
+// ConfigSnapshot returns the publisher's active configuration for debug
+// reporting, with AuthToken and AuthTokens masked as "***". MaxBlobSize and
+// NamespaceID reflect any changes made since the publisher was created.
+func (p *Publisher) ConfigSnapshot() ConfigSnapshot {
+	p.namespaceMu.RLock()
+	config := p.config
+	p.namespaceMu.RUnlock()
+
+	snapshot := ConfigSnapshot{
+		Endpoint:                     config.Endpoint,
+		NamespaceID:                  config.NamespaceID,
+		GasPrice:                     config.GasPrice,
+		MaxBlobSize:                  p.maxBlobSize.Load(),
+		SubmitTimeout:                config.SubmitTimeout,
+		StrictOrdering:               config.StrictOrdering,
+		WorkerCount:                  config.WorkerCount,
+		MetadataStore:                typeName(config.MetadataStore),
+		MetadataCacheSize:            config.MetadataCacheSize,
+		TailBufferSize:               config.TailBufferSize,
+		AutoCompactAfterBatches:      config.AutoCompactAfterBatches,
+		DefaultConfirmations:         config.DefaultConfirmations,
+		TokenCooldownDuration:        config.TokenCooldownDuration,
+		MinServerVersion:             config.MinServerVersion,
+		StrictVersionCheck:           config.StrictVersionCheck,
+		MaxRetries:                   config.MaxRetries,
+		RetryDelay:                   config.RetryDelay,
+		OnError:                      setOrNotSet(config.OnError != nil),
+		SubscribeReconnectDelay:      config.SubscribeReconnectDelay,
+		MaxSubscribeReconnects:       config.MaxSubscribeReconnects,
+		BatchQueueTimeout:            config.BatchQueueTimeout,
+		MaxSnapshotBatches:           config.MaxSnapshotBatches,
+		HeartbeatInterval:            config.HeartbeatInterval,
+		RetrieveTimeout:              config.RetrieveTimeout,
+		RateWindowSeconds:            config.RateWindowSeconds,
+		VerificationMode:             config.VerificationMode,
+		DASSamplingTimeout:           config.DASSamplingTimeout,
+		UTIAUSDPriceFeed:             setOrNotSet(config.UTIAUSDPriceFeed != nil),
+		HeightTargetingTimeout:       config.HeightTargetingTimeout,
+		RetentionPolicy:              typeName(config.RetentionPolicy),
+		EvictionCheckInterval:        config.EvictionCheckInterval,
+		Logger:                       setOrNotSet(config.Logger != nil),
+		AllowFullBlockFetch:          config.AllowFullBlockFetch,
+		UnhealthyAlertRepeatInterval: config.UnhealthyAlertRepeatInterval,
+		RPCTracer:                    setOrNotSet(config.RPCTracer != nil),
+		HeightCacheTTL:               config.HeightCacheTTL,
+		PriorityGasMultiplier:        config.PriorityGasMultiplier,
+		AuditLogPath:                 config.AuditLogPath,
+		MaxSquareSize:                config.MaxSquareSize,
+		Compression:                  config.Compression,
+	}
+
+	if config.AuthToken != "" {
+		snapshot.AuthToken = maskedSecret
+	}
+	if len(config.AuthTokens) > 0 {
+		snapshot.AuthTokens = make([]string, len(config.AuthTokens))
+		for i := range snapshot.AuthTokens {
+			snapshot.AuthTokens[i] = maskedSecret
+		}
+	}
+	return snapshot
+}
+
+func (s ConfigSnapshot) String() string {
+	type plain ConfigSnapshot
+	return fmt.Sprintf("%+v", plain(s))
+}
+
+// MarshalJSON encodes the snapshot with durations written as strings such as
+// "30s" rather than nanosecond counts.
+func (s ConfigSnapshot) MarshalJSON() ([]byte, error) {
+	v := reflect.ValueOf(s)
+	t := v.Type()
+
+	fields := make(map[string]interface{}, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		value := v.Field(i).Interface()
+		if d, ok := value.(time.Duration); ok {
+			value = d.String()
+		}
+		fields[t.Field(i).Tag.Get("json")] = value
+	}
+	return json.Marshal(fields)
+}
+
+func setOrNotSet(set bool) string {
+	if set {
+		return valueSet
+	}
+	return valueNotSet
+}
+
+func typeName(v interface{}) string {
+	if v == nil {
+		return valueNotSet
+	}
+	return fmt.Sprintf("%T", v)
+}
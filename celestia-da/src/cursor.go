@@ -0,0 +1,76 @@
+package celestiada
+
+import (
+	"fmt"
+	"sort"
+)
+
+const defaultCursorPageSize = 100
+
+// BatchMetadataCursor pages through batch metadata in ascending batch number
+// order. The set of batch numbers is captured when the cursor is created, so
+// batches stored afterwards are not returned.
+type BatchMetadataCursor struct {
+	store        MetadataStore
+	batchNumbers []uint64
+	pageSize     int
+	pos          int
+	err          error
+}
+
+func (c *CDKIntegration) NewBatchMetadataCursor(startAfter uint64, pageSize int) *BatchMetadataCursor {
+	if pageSize <= 0 {
+		pageSize = defaultCursorPageSize
+	}
+
+	cursor := &BatchMetadataCursor{
+		store:    c.metadataStore,
+		pageSize: pageSize,
+	}
+
+	cursor.err = c.metadataStore.Range(func(metadata *BatchMetadata) bool {
+		if metadata.BatchNumber > startAfter {
+			cursor.batchNumbers = append(cursor.batchNumbers, metadata.BatchNumber)
+		}
+		return true
+	})
+	sort.Slice(cursor.batchNumbers, func(i, j int) bool {
+		return cursor.batchNumbers[i] < cursor.batchNumbers[j]
+	})
+
+	return cursor
+}
+
+// Next returns the next page. Batches deleted since the cursor was created
+// are skipped, so a page may be shorter than the page size.
+func (bc *BatchMetadataCursor) Next() ([]*BatchMetadata, error) {
+	if bc.err != nil {
+		err := bc.err
+		bc.err = nil
+		bc.pos = len(bc.batchNumbers)
+		return nil, fmt.Errorf("failed to read metadata store: %w", err)
+	}
+
+	end := bc.pos + bc.pageSize
+	if end > len(bc.batchNumbers) {
+		end = len(bc.batchNumbers)
+	}
+
+	page := make([]*BatchMetadata, 0, end-bc.pos)
+	for _, batchNumber := range bc.batchNumbers[bc.pos:end] {
+		metadata, ok, err := bc.store.Load(batchNumber)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load metadata for batch %d: %w", batchNumber, err)
+		}
+		if ok {
+			page = append(page, metadata)
+		}
+	}
+	bc.pos = end
+
+	return page, nil
+}
+
+func (bc *BatchMetadataCursor) HasMore() bool {
+	return bc.err != nil || bc.pos < len(bc.batchNumbers)
+}
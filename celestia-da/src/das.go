@@ -0,0 +1,62 @@
+package celestiada
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	VerificationModeRPC = "rpc"
+	VerificationModeDAS = "das"
+)
+
+const dasPollInterval = time.Second
+
+func validVerificationMode(mode string) bool {
+	switch mode {
+	case "", VerificationModeRPC, VerificationModeDAS:
+		return true
+	}
+	return false
+}
+
+// awaitSampling blocks, in VerificationModeDAS, until the local light node has
+// sampled up to height, so availability of a just-submitted blob has been
+// verified independently. It waits at most Config.DASSamplingTimeout, or
+// Config.SubmitTimeout if that is not set. In any other mode it returns at
+// once.
+func (p *Publisher) awaitSampling(ctx context.Context, height uint64) error {
+	if p.config.VerificationMode != VerificationModeDAS {
+		return nil
+	}
+
+	timeout := p.config.DASSamplingTimeout
+	if timeout <= 0 {
+		timeout = p.config.SubmitTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(dasPollInterval)
+	defer ticker.Stop()
+
+	for {
+		rpcStart := time.Now()
+		stats, err := p.client.DAS.SamplingStats(ctx)
+		p.traceRPC("DAS.SamplingStats", rpcStart, err)
+		if err != nil {
+			return fmt.Errorf("failed to get sampling stats: %w", err)
+		}
+		if stats.SampledChainHead >= height {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for height %d to be sampled (sampled up to %d): %w",
+				height, stats.SampledChainHead, ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package celestiada
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	envelopeLengthPrefixSize = 4
+	envelopeHeaderSize       = 1 + 8 + 8
+)
+
+// BlobEnvelope carries application metadata inside the blob itself. It is
+// encoded as a 4-byte big-endian header length, the header (version, chain ID,
+// sequence number) and then the payload.
+type BlobEnvelope struct {
+	Version        uint8
+	ChainID        uint64
+	SequenceNumber uint64
+	Payload        []byte
+}
+
+func (e *BlobEnvelope) encode() []byte {
+	buf := make([]byte, envelopeLengthPrefixSize+envelopeHeaderSize, envelopeLengthPrefixSize+envelopeHeaderSize+len(e.Payload))
+	binary.BigEndian.PutUint32(buf[0:4], envelopeHeaderSize)
+	buf[4] = e.Version
+	binary.BigEndian.PutUint64(buf[5:13], e.ChainID)
+	binary.BigEndian.PutUint64(buf[13:21], e.SequenceNumber)
+	return append(buf, e.Payload...)
+}
+
+func decodeEnvelope(data []byte) (*BlobEnvelope, error) {
+	if len(data) < envelopeLengthPrefixSize {
+		return nil, fmt.Errorf("envelope too short: %d bytes", len(data))
+	}
+
+	headerLen := binary.BigEndian.Uint32(data[0:4])
+	if headerLen < envelopeHeaderSize || uint64(len(data)-envelopeLengthPrefixSize) < uint64(headerLen) {
+		return nil, fmt.Errorf("invalid envelope header length: %d", headerLen)
+	}
+
+	header := data[envelopeLengthPrefixSize:]
+	return &BlobEnvelope{
+		Version:        header[0],
+		ChainID:        binary.BigEndian.Uint64(header[1:9]),
+		SequenceNumber: binary.BigEndian.Uint64(header[9:17]),
+		Payload:        header[headerLen:],
+	}, nil
+}
+
+func (p *Publisher) SubmitEnvelope(ctx context.Context, envelope *BlobEnvelope) (string, error) {
+	return p.PublishBatch(ctx, envelope.encode())
+}
+
+func (p *Publisher) RetrieveEnvelope(ctx context.Context, height uint64, commitment string) (*BlobEnvelope, error) {
+	data, err := p.RetrieveBatch(ctx, height, commitment)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope, err := decodeEnvelope(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode envelope: %w", err)
+	}
+	return envelope, nil
+}
+
+// LabeledBlob is a payload tagged with labels that travel inside the blob. It
+// is encoded as a 4-byte big-endian header length, the labels as a JSON
+// object and then the payload, so a reader can inspect the labels without
+// parsing the payload. Blobs are fetched whole, though, so filtering by label
+// still downloads every candidate blob.
+type LabeledBlob struct {
+	Labels  map[string]string
+	Payload []byte
+}
+
+func (lb *LabeledBlob) encode() ([]byte, error) {
+	header, err := json.Marshal(lb.Labels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode labels: %w", err)
+	}
+
+	buf := make([]byte, envelopeLengthPrefixSize, envelopeLengthPrefixSize+len(header)+len(lb.Payload))
+	binary.BigEndian.PutUint32(buf, uint32(len(header)))
+	buf = append(buf, header...)
+	return append(buf, lb.Payload...), nil
+}
+
+func decodeLabeledBlob(data []byte) (*LabeledBlob, error) {
+	if len(data) < envelopeLengthPrefixSize {
+		return nil, fmt.Errorf("labeled blob too short: %d bytes", len(data))
+	}
+
+	headerLen := binary.BigEndian.Uint32(data[0:4])
+	if uint64(len(data)-envelopeLengthPrefixSize) < uint64(headerLen) {
+		return nil, fmt.Errorf("invalid labeled blob header length: %d", headerLen)
+	}
+
+	header := data[envelopeLengthPrefixSize : envelopeLengthPrefixSize+headerLen]
+	var labels map[string]string
+	if err := json.Unmarshal(header, &labels); err != nil {
+		return nil, fmt.Errorf("invalid labeled blob header: %w", err)
+	}
+
+	return &LabeledBlob{
+		Labels:  labels,
+		Payload: data[envelopeLengthPrefixSize+headerLen:],
+	}, nil
+}
+
+// SubmitLabeledBlob encodes lb with its labels ahead of the payload and
+// publishes it like PublishBatch, returning the blob's ref ID.
+func (p *Publisher) SubmitLabeledBlob(ctx context.Context, lb *LabeledBlob) (string, error) {
+	data, err := lb.encode()
+	if err != nil {
+		return "", err
+	}
+	return p.PublishBatch(ctx, data)
+}
+
+// RetrieveLabeledBlob fetches the blob at height and commitment and decodes
+// it as a LabeledBlob. Blobs not written by SubmitLabeledBlob fail to decode.
+func (p *Publisher) RetrieveLabeledBlob(ctx context.Context, height uint64, commitment string) (*LabeledBlob, error) {
+	data, err := p.RetrieveBatch(ctx, height, commitment)
+	if err != nil {
+		return nil, err
+	}
+
+	lb, err := decodeLabeledBlob(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode labeled blob: %w", err)
+	}
+	return lb, nil
+}
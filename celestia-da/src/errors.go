@@ -0,0 +1,21 @@
+package celestiada
+
+import "errors"
+
+var (
+	ErrBatchNotFound          = errors.New("batch not found")
+	ErrBatchCancelled         = errors.New("batch cancelled")
+	ErrAlreadyAttached        = errors.New("metrics recorder already attached")
+	ErrNoBatches              = errors.New("no batches have been published")
+	ErrSnapshotTooLarge       = errors.New("metadata snapshot too large")
+	ErrQueueFull              = errors.New("batch queue is full")
+	ErrHeightMissed           = errors.New("target height missed")
+	ErrFullBlockFetchDisabled = errors.New("full block fetch is disabled")
+	ErrDeadlineExceeded       = errors.New("batch deadline exceeded")
+	ErrFeeLimitExceeded       = errors.New("estimated fee exceeds limit")
+	ErrBatchAlreadyExists     = errors.New("batch metadata already exists")
+	ErrBlobTooLarge           = errors.New("blob too large")
+	ErrListenerExists         = errors.New("batch listener already registered")
+	ErrListenerNotFound       = errors.New("batch listener not registered")
+	ErrLargeBlobNotIndexed    = errors.New("large blob shard locations not known")
+)
@@ -0,0 +1,132 @@
+package celestiada
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+// Constants from Celestia's PayForBlobs fee model.
+const (
+	gasPerBlobByte    = 8
+	pfbGasFixedCost   = 75000
+	bytesPerBlobInfo  = 70
+	txSizeCostPerByte = 10
+)
+
+type EstimateResult struct {
+	IsValid                bool
+	WouldExceedMaxBlobSize bool
+	EstimatedGasUnits      uint64
+	EstimatedCostUTIA      float64
+	PaddedShareCount       int
+	CompressionRatio       float64
+}
+
+func estimateBlobGas(paddedShares int) uint64 {
+	return uint64(paddedShares)*shareSize*gasPerBlobByte + txSizeCostPerByte*bytesPerBlobInfo + pfbGasFixedCost
+}
+
+// estimateDataGas estimates the gas a PayForBlobs transaction for data uses.
+// Blob.Submit does not report gas used, so the fee model is the only source.
+func estimateDataGas(data []byte) uint64 {
+	_, shares, err := BatchDataSize(data, share.DefaultShareVersion)
+	if err != nil {
+		return 0
+	}
+	return estimateBlobGas(shares)
+}
+
+// DryRunEstimate computes what submitting data would cost without making any
+// RPC calls. When Config.Compression is set, the estimate is for data
+// compressed as CDKIntegration publishes it, and CompressionRatio is the
+// uncompressed size over the compressed size; otherwise it is 1.
+func (p *Publisher) DryRunEstimate(data []byte) (*EstimateResult, error) {
+	if p.config.Compression == "" {
+		return p.estimate(data)
+	}
+
+	compressed, err := compressData(data, p.config.Compression)
+	if err != nil {
+		return nil, err
+	}
+	estimate, err := p.estimate(compressed)
+	if err != nil {
+		return nil, err
+	}
+	estimate.CompressionRatio = float64(len(data)) / float64(len(compressed))
+	return estimate, nil
+}
+
+// estimate computes what submitting data, exactly as given, would cost.
+func (p *Publisher) estimate(data []byte) (*EstimateResult, error) {
+	_, shares, err := BatchDataSize(data, share.DefaultShareVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid batch data: %w", err)
+	}
+
+	gas := estimateBlobGas(shares)
+	exceeds := uint64(shares)*shareSize > p.maxBlobSize.Load()
+
+	return &EstimateResult{
+		IsValid:                !exceeds,
+		WouldExceedMaxBlobSize: exceeds,
+		EstimatedGasUnits:      gas,
+		EstimatedCostUTIA:      float64(gas) * p.config.GasPrice,
+		PaddedShareCount:       shares,
+		CompressionRatio:       1,
+	}, nil
+}
+
+// PublishBatchWithFeeLimit publishes data only if its estimated fee is at
+// most maxFeeUTIA. The fee is estimated at Config.GasPrice or, if that is
+// not set, at the gas price the node currently estimates, and data is
+// submitted at that same price. Otherwise it returns an error wrapping
+// ErrFeeLimitExceeded without submitting anything.
+func (p *Publisher) PublishBatchWithFeeLimit(ctx context.Context, data []byte, maxFeeUTIA uint64) (string, error) {
+	gasPrice, err := p.feeGasPrice(ctx, false)
+	if err != nil {
+		return "", err
+	}
+	if err := p.checkFeeLimit(data, maxFeeUTIA, gasPrice); err != nil {
+		return "", err
+	}
+	return p.publish(ctx, p.currentNamespace(), data, 0, gasPrice)
+}
+
+// feeGasPrice returns the gas price to check a fee cap at and then submit
+// at. Without Config.GasPrice the node picks the price at submission, which
+// would leave the cap unchecked, so the node's current estimate is fetched
+// and used instead. Config.PriorityGasMultiplier applies as in gasPrice.
+func (p *Publisher) feeGasPrice(ctx context.Context, highPriority bool) (float64, error) {
+	if p.config.GasPrice > 0 {
+		return p.gasPrice(highPriority), nil
+	}
+
+	rpcStart := time.Now()
+	estimated, err := p.client.State.EstimateGasPrice(ctx)
+	p.traceRPC("State.EstimateGasPrice", rpcStart, err)
+	if err != nil {
+		return 0, fmt.Errorf("failed to estimate gas price: %w", err)
+	}
+	if estimated <= 0 {
+		return 0, fmt.Errorf("node estimated an invalid gas price: %v", estimated)
+	}
+	return p.priorityGasPrice(estimated, highPriority), nil
+}
+
+// checkFeeLimit estimates the fee for data at gasPrice, the price it will
+// actually be submitted at, and fails with ErrFeeLimitExceeded if it is over
+// maxFeeUTIA.
+func (p *Publisher) checkFeeLimit(data []byte, maxFeeUTIA uint64, gasPrice float64) error {
+	estimate, err := p.estimate(data)
+	if err != nil {
+		return err
+	}
+	if cost := float64(estimate.EstimatedGasUnits) * gasPrice; cost > float64(maxFeeUTIA) {
+		return fmt.Errorf("%w: estimated %.0f utia > limit %d utia", ErrFeeLimitExceeded, cost, maxFeeUTIA)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package celestiada
+
+import (
+	"context"
+	"time"
+)
+
+const failureWatcherBuffer = 64
+
+type BatchFailure struct {
+	BatchNumber uint64
+	Error       error
+	Attempts    int
+	QueuedAt    time.Time
+}
+
+// WatchBatchFailures returns a channel that receives every batch that fails
+// after exhausting its retries, including the fallback DA layer if one is
+// set. Each call gets its own channel. Workers never block on watchers: once
+// a channel's buffer is full, further failures are dropped for that watcher
+// until it catches up. The channel is closed when ctx is done or the
+// integration is closed.
+func (c *CDKIntegration) WatchBatchFailures(ctx context.Context) <-chan BatchFailure {
+	failures := make(chan BatchFailure, failureWatcherBuffer)
+
+	c.failureMu.Lock()
+	c.failureSubs[failures] = struct{}{}
+	c.failureMu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-c.ctx.Done():
+		}
+
+		c.failureMu.Lock()
+		delete(c.failureSubs, failures)
+		close(failures)
+		c.failureMu.Unlock()
+	}()
+
+	return failures
+}
+
+func (c *CDKIntegration) notifyFailure(batch *BatchData, err error, attempts int) {
+	c.broadcastFailure(BatchFailure{
+		BatchNumber: batch.Number,
+		Error:       err,
+		Attempts:    attempts,
+		QueuedAt:    batch.queuedAt,
+	})
+}
+
+func (c *CDKIntegration) broadcastFailure(failure BatchFailure) {
+	c.failureMu.Lock()
+	defer c.failureMu.Unlock()
+
+	for failures := range c.failureSubs {
+		select {
+		case failures <- failure:
+		default:
+		}
+	}
+}
@@ -0,0 +1,560 @@
+package celestiada
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+var (
+	_ batchPublisher = (*FakePublisher)(nil)
+	_ PublisherIface = (*RecordingPublisher)(nil)
+)
+
+// FakePublisher is an in-memory publisher for tests, and can stand in for
+// Publisher behind a CDKIntegration built with newCDKIntegration. Each
+// submission is stored at the next height, with the SHA-256 of the data as
+// its commitment. Every payload published and every commitment retrieved is
+// recorded for assertions. Set the exported fields before use, and read the
+// recorded fields only once the calls under test have returned.
+type FakePublisher struct {
+	PublishedBlobs       [][]byte
+	RetrievedCommitments []string
+
+	PublishErr   error
+	RetrieveErr  error
+	PublishDelay time.Duration
+
+	mu        sync.Mutex
+	height    uint64
+	blobs     map[string][]byte
+	namespace share.Namespace
+	closed    bool
+}
+
+func NewFakePublisher() *FakePublisher {
+	return &FakePublisher{
+		blobs: make(map[string][]byte),
+	}
+}
+
+func (f *FakePublisher) wait(ctx context.Context) error {
+	if f.PublishDelay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(f.PublishDelay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// store records payloads at a single new height and returns their ref IDs.
+// The caller must hold f.mu.
+func (f *FakePublisher) store(payloads [][]byte) []string {
+	if f.blobs == nil {
+		f.blobs = make(map[string][]byte)
+	}
+	f.height++
+
+	refIDs := make([]string, len(payloads))
+	for i, payload := range payloads {
+		data := append([]byte(nil), payload...)
+		sum := sha256.Sum256(data)
+		refID := fmt.Sprintf("%d:%s", f.height, hex.EncodeToString(sum[:]))
+
+		f.PublishedBlobs = append(f.PublishedBlobs, data)
+		f.blobs[refID] = data
+		refIDs[i] = refID
+	}
+	return refIDs
+}
+
+func (f *FakePublisher) PublishBatch(ctx context.Context, batchData []byte) (string, error) {
+	if err := f.wait(ctx); err != nil {
+		return "", fmt.Errorf("failed to submit blob: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return "", fmt.Errorf("publisher is closed")
+	}
+	if f.PublishErr != nil {
+		return "", fmt.Errorf("failed to submit blob: %w", f.PublishErr)
+	}
+
+	return f.store([][]byte{batchData})[0], nil
+}
+
+func (f *FakePublisher) SubmitBlobs(ctx context.Context, payloads [][]byte) []BlobSubmitResult {
+	results := make([]BlobSubmitResult, len(payloads))
+
+	err := f.wait(ctx)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case err != nil:
+	case f.closed:
+		err = fmt.Errorf("publisher is closed")
+	case f.PublishErr != nil:
+		err = f.PublishErr
+	}
+	if err != nil {
+		for i := range results {
+			results[i].Error = fmt.Errorf("failed to submit blobs: %w", err)
+		}
+		return results
+	}
+
+	for i, refID := range f.store(payloads) {
+		results[i].RefID = refID
+	}
+	return results
+}
+
+func (f *FakePublisher) submitBlobs(ctx context.Context, payloads [][]byte, _ float64) []BlobSubmitResult {
+	return f.SubmitBlobs(ctx, payloads)
+}
+
+// SubmitAndPoll publishes data and reports it as confirmed immediately, with
+// the network head at exactly the requested depth.
+func (f *FakePublisher) SubmitAndPoll(ctx context.Context, data []byte, confirmations uint64) (string, uint64, error) {
+	refID, err := f.PublishBatch(ctx, data)
+	if err != nil {
+		return "", 0, err
+	}
+
+	height, _, err := parseRefID(refID)
+	if err != nil {
+		return "", 0, err
+	}
+	return refID, height + confirmations, nil
+}
+
+func (f *FakePublisher) SubmitInBackground(ctx context.Context, data []byte) <-chan PublishBackgroundResult {
+	resultChan := make(chan PublishBackgroundResult, 1)
+
+	go func() {
+		start := time.Now()
+		refID, err := f.PublishBatch(ctx, data)
+		resultChan <- PublishBackgroundResult{
+			RefID:    refID,
+			Error:    err,
+			Duration: time.Since(start),
+		}
+	}()
+
+	return resultChan
+}
+
+func (f *FakePublisher) RetrieveBatch(ctx context.Context, height uint64, commitment string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.RetrievedCommitments = append(f.RetrievedCommitments, commitment)
+
+	if f.RetrieveErr != nil {
+		return nil, fmt.Errorf("failed to get blob: %w", f.RetrieveErr)
+	}
+
+	data, ok := f.blobs[fmt.Sprintf("%d:%s", height, commitment)]
+	if !ok {
+		return nil, fmt.Errorf("failed to get blob: blob: not found")
+	}
+	return append([]byte(nil), data...), nil
+}
+
+func (f *FakePublisher) GetBlobSize(ctx context.Context, height uint64, commitment string) (uint64, error) {
+	data, err := f.RetrieveBatch(ctx, height, commitment)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(len(data)), nil
+}
+
+func (f *FakePublisher) GetBlobsByCommitments(ctx context.Context, refs []BlobRef) ([][]byte, error) {
+	results := make([][]byte, len(refs))
+	for i, ref := range refs {
+		data, err := f.RetrieveBatch(ctx, ref.Height, ref.Commitment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get blob %d:%s: %w", ref.Height, ref.Commitment, err)
+		}
+		results[i] = data
+	}
+	return results, nil
+}
+
+func (f *FakePublisher) GetBlobs(ctx context.Context, refIDs ...string) ([][]byte, error) {
+	refs := make([]BlobRef, len(refIDs))
+	for i, refID := range refIDs {
+		height, commitment, err := parseRefID(refID)
+		if err != nil {
+			return nil, err
+		}
+		refs[i] = BlobRef{Height: height, Commitment: commitment}
+	}
+
+	return f.GetBlobsByCommitments(ctx, refs)
+}
+
+func (f *FakePublisher) PaginatedGet(ctx context.Context, height uint64, commitment string, chunkSize int) (<-chan BlobChunk, error) {
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("invalid chunk size: %d", chunkSize)
+	}
+
+	data, err := f.RetrieveBatch(ctx, height, commitment)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan BlobChunk)
+
+	go func() {
+		defer close(chunks)
+
+		for offset := 0; offset == 0 || offset < len(data); offset += chunkSize {
+			end := offset + chunkSize
+			if end > len(data) {
+				end = len(data)
+			}
+
+			select {
+			case chunks <- BlobChunk{
+				Offset: offset,
+				Data:   data[offset:end],
+				IsLast: end == len(data),
+			}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return chunks, nil
+}
+
+func (f *FakePublisher) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.closed = true
+	return nil
+}
+
+// fakeNamespace is the namespace a FakePublisher starts in.
+var fakeNamespace = share.Namespace(append(make([]byte, namespaceSize-1), 1))
+
+func (f *FakePublisher) Namespace() string {
+	return hex.EncodeToString(f.currentNamespace())
+}
+
+func (f *FakePublisher) currentNamespace() share.Namespace {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.namespace == nil {
+		return fakeNamespace
+	}
+	return f.namespace
+}
+
+func (f *FakePublisher) setNamespace(namespaceID string) error {
+	namespace, err := parseNamespaceID(namespaceID)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.namespace = namespace
+	return nil
+}
+
+func (f *FakePublisher) setMaxBlobSize(uint64) {}
+
+// gasPrice returns 1, or 2 for high-priority submissions.
+func (f *FakePublisher) gasPrice(highPriority bool) float64 {
+	if highPriority {
+		return 2
+	}
+	return 1
+}
+
+// feeGasPrice returns the same prices as gasPrice.
+func (f *FakePublisher) feeGasPrice(_ context.Context, highPriority bool) (float64, error) {
+	return f.gasPrice(highPriority), nil
+}
+
+// checkFeeLimit applies the real fee model.
+func (f *FakePublisher) checkFeeLimit(data []byte, maxFeeUTIA uint64, gasPrice float64) error {
+	if fee := float64(estimateDataGas(data)) * gasPrice; fee > float64(maxFeeUTIA) {
+		return fmt.Errorf("%w: estimated %.0f utia > limit %d utia", ErrFeeLimitExceeded, fee, maxFeeUTIA)
+	}
+	return nil
+}
+
+func (f *FakePublisher) publish(ctx context.Context, _ share.Namespace, batchData []byte, _ time.Duration, _ float64) (string, error) {
+	return f.PublishBatch(ctx, batchData)
+}
+
+func (f *FakePublisher) submitAndPoll(ctx context.Context, data []byte, confirmations uint64, _ time.Duration, _ float64) (string, uint64, error) {
+	return f.SubmitAndPoll(ctx, data, confirmations)
+}
+
+func (f *FakePublisher) confirm(context.Context, unconfirmedError, uint64) error {
+	return nil
+}
+
+func (f *FakePublisher) getBlob(ctx context.Context, namespace share.Namespace, height uint64, commitment string, _ time.Duration) (*blob.Blob, error) {
+	data, err := f.RetrieveBatch(ctx, height, commitment)
+	if err != nil {
+		return nil, err
+	}
+	return &blob.Blob{Namespace: namespace, Data: data}, nil
+}
+
+func (f *FakePublisher) retrieve(ctx context.Context, _ share.Namespace, height uint64, commitment string, _ time.Duration) ([]byte, error) {
+	return f.RetrieveBatch(ctx, height, commitment)
+}
+
+// namespaceTreeProof fails: the fake keeps no data square to prove against.
+func (f *FakePublisher) namespaceTreeProof(context.Context, share.Namespace, uint64, string) (*NMTProof, error) {
+	return nil, fmt.Errorf("NMT proofs are not supported by FakePublisher")
+}
+
+// GetNamespaceBlobs streams every stored blob in the range, ignoring
+// namespaces.
+func (f *FakePublisher) GetNamespaceBlobs(ctx context.Context, fromHeight, toHeight uint64) (<-chan NamespaceBlobResult, error) {
+	if fromHeight == 0 || fromHeight > toHeight {
+		return nil, fmt.Errorf("invalid height range: %d-%d", fromHeight, toHeight)
+	}
+
+	f.mu.Lock()
+	var found []NamespaceBlobResult
+	for refID, data := range f.blobs {
+		height, commitment, _ := parseRefID(refID)
+		if height >= fromHeight && height <= toHeight {
+			found = append(found, NamespaceBlobResult{
+				Height:     height,
+				Commitment: commitment,
+				Data:       append([]byte(nil), data...),
+			})
+		}
+	}
+	f.mu.Unlock()
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Height != found[j].Height {
+			return found[i].Height < found[j].Height
+		}
+		return found[i].Commitment < found[j].Commitment
+	})
+
+	results := make(chan NamespaceBlobResult, len(found))
+	for _, result := range found {
+		results <- result
+	}
+	close(results)
+	return results, nil
+}
+
+// SubscribeNamespace returns a subscription that delivers nothing and closes
+// when ctx is done.
+func (f *FakePublisher) SubscribeNamespace(ctx context.Context) (<-chan BlobEvent, error) {
+	events := make(chan BlobEvent)
+	go func() {
+		<-ctx.Done()
+		close(events)
+	}()
+	return events, nil
+}
+
+func (f *FakePublisher) Ping(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return fmt.Errorf("failed to ping node: publisher is closed")
+	}
+	return nil
+}
+
+func (f *FakePublisher) fallbackDA() FallbackDA {
+	return nil
+}
+
+func (f *FakePublisher) submitFallback(context.Context, []byte) (string, error) {
+	return "", fmt.Errorf("no fallback DA layer configured")
+}
+
+func (f *FakePublisher) RetrieveFallback(context.Context, string) ([]byte, error) {
+	return nil, fmt.Errorf("no fallback DA layer configured")
+}
+
+// networkHead reports the height of the last submission.
+func (f *FakePublisher) networkHead(context.Context) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.height, nil
+}
+
+// blockTime reports height seconds after the Unix epoch.
+func (f *FakePublisher) blockTime(_ context.Context, height uint64) (time.Time, error) {
+	return time.Unix(int64(height), 0).UTC(), nil
+}
+
+// PublisherCall is one call observed by a RecordingPublisher.
+type PublisherCall struct {
+	Method  string   `json:"method"`
+	Inputs  []string `json:"inputs,omitempty"`
+	Outputs []string `json:"outputs,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// RecordingPublisher wraps another PublisherIface and records the inputs and
+// outputs of each call, in call order, so tests can compare them with golden
+// files. Byte payloads are recorded hex-encoded. Streaming results from
+// SubmitInBackground and PaginatedGet are passed through unrecorded; only
+// the call itself is logged.
+type RecordingPublisher struct {
+	inner PublisherIface
+
+	mu    sync.Mutex
+	calls []PublisherCall
+}
+
+func NewRecordingPublisher(inner PublisherIface) *RecordingPublisher {
+	return &RecordingPublisher{inner: inner}
+}
+
+// Calls returns a copy of the calls recorded so far.
+func (r *RecordingPublisher) Calls() []PublisherCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	calls := make([]PublisherCall, len(r.calls))
+	copy(calls, r.calls)
+	return calls
+}
+
+func (r *RecordingPublisher) record(method string, inputs, outputs []string, err error) {
+	call := PublisherCall{
+		Method:  method,
+		Inputs:  inputs,
+		Outputs: outputs,
+	}
+	if err != nil {
+		call.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	r.calls = append(r.calls, call)
+	r.mu.Unlock()
+}
+
+func hexAll(data [][]byte) []string {
+	out := make([]string, len(data))
+	for i, d := range data {
+		out[i] = hex.EncodeToString(d)
+	}
+	return out
+}
+
+func (r *RecordingPublisher) PublishBatch(ctx context.Context, batchData []byte) (string, error) {
+	refID, err := r.inner.PublishBatch(ctx, batchData)
+	r.record("PublishBatch", hexAll([][]byte{batchData}), []string{refID}, err)
+	return refID, err
+}
+
+func (r *RecordingPublisher) SubmitBlobs(ctx context.Context, payloads [][]byte) []BlobSubmitResult {
+	results := r.inner.SubmitBlobs(ctx, payloads)
+
+	outputs := make([]string, len(results))
+	for i, result := range results {
+		if result.Error != nil {
+			outputs[i] = "error: " + result.Error.Error()
+		} else {
+			outputs[i] = result.RefID
+		}
+	}
+	r.record("SubmitBlobs", hexAll(payloads), outputs, nil)
+	return results
+}
+
+func (r *RecordingPublisher) SubmitAndPoll(ctx context.Context, data []byte, confirmations uint64) (string, uint64, error) {
+	refID, head, err := r.inner.SubmitAndPoll(ctx, data, confirmations)
+	r.record("SubmitAndPoll",
+		[]string{hex.EncodeToString(data), fmt.Sprint(confirmations)},
+		[]string{refID, fmt.Sprint(head)}, err)
+	return refID, head, err
+}
+
+func (r *RecordingPublisher) SubmitInBackground(ctx context.Context, data []byte) <-chan PublishBackgroundResult {
+	r.record("SubmitInBackground", hexAll([][]byte{data}), nil, nil)
+	return r.inner.SubmitInBackground(ctx, data)
+}
+
+func (r *RecordingPublisher) RetrieveBatch(ctx context.Context, height uint64, commitment string) ([]byte, error) {
+	data, err := r.inner.RetrieveBatch(ctx, height, commitment)
+	r.record("RetrieveBatch",
+		[]string{fmt.Sprint(height), commitment},
+		hexAll([][]byte{data}), err)
+	return data, err
+}
+
+func (r *RecordingPublisher) GetBlobSize(ctx context.Context, height uint64, commitment string) (uint64, error) {
+	size, err := r.inner.GetBlobSize(ctx, height, commitment)
+	r.record("GetBlobSize",
+		[]string{fmt.Sprint(height), commitment},
+		[]string{fmt.Sprint(size)}, err)
+	return size, err
+}
+
+func (r *RecordingPublisher) GetBlobsByCommitments(ctx context.Context, refs []BlobRef) ([][]byte, error) {
+	data, err := r.inner.GetBlobsByCommitments(ctx, refs)
+
+	inputs := make([]string, len(refs))
+	for i, ref := range refs {
+		inputs[i] = fmt.Sprintf("%d:%s", ref.Height, ref.Commitment)
+	}
+	r.record("GetBlobsByCommitments", inputs, hexAll(data), err)
+	return data, err
+}
+
+func (r *RecordingPublisher) GetBlobs(ctx context.Context, refIDs ...string) ([][]byte, error) {
+	data, err := r.inner.GetBlobs(ctx, refIDs...)
+	r.record("GetBlobs", refIDs, hexAll(data), err)
+	return data, err
+}
+
+func (r *RecordingPublisher) PaginatedGet(ctx context.Context, height uint64, commitment string, chunkSize int) (<-chan BlobChunk, error) {
+	chunks, err := r.inner.PaginatedGet(ctx, height, commitment, chunkSize)
+	r.record("PaginatedGet",
+		[]string{fmt.Sprint(height), commitment, fmt.Sprint(chunkSize)},
+		nil, err)
+	return chunks, err
+}
+
+func (r *RecordingPublisher) Close() error {
+	err := r.inner.Close()
+	r.record("Close", nil, nil, err)
+	return err
+}
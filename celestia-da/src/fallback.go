@@ -0,0 +1,122 @@
+package celestiada
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	DALayerCelestia = "celestia"
+	DALayerFallback = "fallback"
+)
+
+// FallbackDA is an alternative data availability layer, such as Ethereum
+// calldata or EigenDA, used when Celestia submission fails. Ref IDs are
+// opaque to this package and are only ever passed back to Retrieve.
+type FallbackDA interface {
+	Submit(ctx context.Context, data []byte) (refID string, err error)
+	Retrieve(ctx context.Context, refID string) ([]byte, error)
+}
+
+// SetFallback installs the DA layer used when Celestia submission fails. A
+// nil fb disables the fallback.
+func (p *Publisher) SetFallback(fb FallbackDA) {
+	p.fallbackMu.Lock()
+	defer p.fallbackMu.Unlock()
+
+	p.fallback = fb
+}
+
+func (p *Publisher) fallbackDA() FallbackDA {
+	p.fallbackMu.RLock()
+	defer p.fallbackMu.RUnlock()
+
+	return p.fallback
+}
+
+// SubmitWithFallback publishes data to Celestia and, if that fails, to the
+// fallback DA layer. It reports which layer holds the data as DALayerCelestia
+// or DALayerFallback. The Celestia error is returned unchanged when no
+// fallback is set.
+func (p *Publisher) SubmitWithFallback(ctx context.Context, data []byte) (refID string, layer string, err error) {
+	refID, err = p.PublishBatch(ctx, data)
+	if err == nil {
+		return refID, DALayerCelestia, nil
+	}
+
+	if p.fallbackDA() == nil {
+		return "", "", err
+	}
+
+	refID, fbErr := p.submitFallback(ctx, data)
+	if fbErr != nil {
+		return "", "", fmt.Errorf("%w (fallback: %v)", err, fbErr)
+	}
+	return refID, DALayerFallback, nil
+}
+
+func (p *Publisher) submitFallback(ctx context.Context, data []byte) (string, error) {
+	fb := p.fallbackDA()
+	if fb == nil {
+		return "", fmt.Errorf("no fallback DA layer set")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.config.SubmitTimeout)
+	defer cancel()
+
+	refID, err := fb.Submit(ctx, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to submit to fallback DA: %w", err)
+	}
+	return refID, nil
+}
+
+// RetrieveFallback fetches data that was published to the fallback DA layer.
+func (p *Publisher) RetrieveFallback(ctx context.Context, refID string) ([]byte, error) {
+	fb := p.fallbackDA()
+	if fb == nil {
+		return nil, fmt.Errorf("no fallback DA layer set")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.config.SubmitTimeout)
+	defer cancel()
+
+	data, err := fb.Retrieve(ctx, refID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve from fallback DA: %w", err)
+	}
+	return data, nil
+}
+
+// recordFallback stores metadata for a batch that landed on the fallback DA
+// layer as payload. No Celestia height, commitment or gas applies to it.
+func (c *CDKIntegration) recordFallback(batch *BatchData, payload []byte, refID string) PublishResult {
+	metadata := &BatchMetadata{
+		BatchNumber:   batch.Number,
+		StateRoot:     batch.StateRoot,
+		Timestamp:     time.Now(),
+		TxCount:       batch.TxCount,
+		RefID:         refID,
+		Labels:        batch.Labels,
+		Size:          uint64(len(batch.Data)),
+		DALayer:       DALayerFallback,
+		SchemaVersion: MetadataSchemaVersion,
+	}
+	c.recordCompression(metadata, payload)
+
+	if err := c.storeMetadata(metadata); err != nil {
+		return PublishResult{
+			Success: false,
+			RefID:   refID,
+			Error:   fmt.Errorf("failed to store metadata for batch %d: %w", batch.Number, err),
+		}
+	}
+	c.fallbacks.Add(1)
+
+	return PublishResult{
+		Success:  true,
+		RefID:    refID,
+		Metadata: metadata,
+	}
+}
@@ -0,0 +1,236 @@
+package celestiada
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+type fileStoreRecord struct {
+	Op          string         `json:"op"`
+	BatchNumber uint64         `json:"batchNumber,omitempty"`
+	Metadata    *BatchMetadata `json:"metadata,omitempty"`
+}
+
+// FileMetadataStore keeps metadata in memory and appends every change to a
+// JSON lines log on disk, synced after each write. Updates and deletes leave
+// stale records behind until the log is rewritten by Compact.
+type FileMetadataStore struct {
+	path string
+
+	mu      sync.RWMutex
+	file    *os.File
+	entries map[uint64]*BatchMetadata
+}
+
+// NewFileMetadataStore opens the log at path, replaying any existing records,
+// and creates it if it does not exist.
+func NewFileMetadataStore(path string) (*FileMetadataStore, error) {
+	s := &FileMetadataStore{
+		path:    path,
+		entries: make(map[uint64]*BatchMetadata),
+	}
+
+	if err := s.replay(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metadata file: %w", err)
+	}
+	s.file = file
+
+	return s, nil
+}
+
+func (s *FileMetadataStore) replay() error {
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open metadata file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record fileStoreRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("invalid metadata record on line %d: %w", line, err)
+		}
+
+		switch record.Op {
+		case "put":
+			if record.Metadata == nil {
+				return fmt.Errorf("metadata record on line %d has no metadata", line)
+			}
+			s.entries[record.Metadata.BatchNumber] = record.Metadata
+		case "delete":
+			delete(s.entries, record.BatchNumber)
+		default:
+			return fmt.Errorf("unknown metadata operation %q on line %d", record.Op, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read metadata file: %w", err)
+	}
+	return nil
+}
+
+func (s *FileMetadataStore) append(record fileStoreRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata record: %w", err)
+	}
+
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write metadata file: %w", err)
+	}
+	return s.file.Sync()
+}
+
+func (s *FileMetadataStore) Load(batchNumber uint64) (*BatchMetadata, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	metadata, ok := s.entries[batchNumber]
+	return metadata, ok, nil
+}
+
+func (s *FileMetadataStore) Store(metadata *BatchMetadata) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.append(fileStoreRecord{Op: "put", Metadata: metadata}); err != nil {
+		return err
+	}
+	s.entries[metadata.BatchNumber] = metadata
+	return nil
+}
+
+func (s *FileMetadataStore) Delete(batchNumber uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[batchNumber]; !ok {
+		return nil
+	}
+	if err := s.append(fileStoreRecord{Op: "delete", BatchNumber: batchNumber}); err != nil {
+		return err
+	}
+	delete(s.entries, batchNumber)
+	return nil
+}
+
+func (s *FileMetadataStore) Range(fn func(metadata *BatchMetadata) bool) error {
+	s.mu.RLock()
+	entries := make([]*BatchMetadata, 0, len(s.entries))
+	for _, metadata := range s.entries {
+		entries = append(entries, metadata)
+	}
+	s.mu.RUnlock()
+
+	for _, metadata := range entries {
+		if !fn(metadata) {
+			break
+		}
+	}
+	return nil
+}
+
+// Compact replaces the log with one put record per entry, sorted by batch
+// number and migrated to the current schema version. The whole rewrite runs
+// under the store's lock, so writes made during compaction wait for it
+// rather than being lost. The new log is written to a temporary file and
+// renamed into place.
+func (s *FileMetadataStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]*BatchMetadata, 0, len(s.entries))
+	for _, metadata := range s.entries {
+		migrated, err := migrateMetadata(metadata)
+		if err != nil {
+			return fmt.Errorf("failed to migrate metadata: %w", err)
+		}
+		entries = append(entries, migrated)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].BatchNumber < entries[j].BatchNumber
+	})
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".compact-*")
+	if err != nil {
+		return fmt.Errorf("failed to create compacted metadata file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	compacted := make(map[uint64]*BatchMetadata, len(entries))
+	for _, metadata := range entries {
+		line, err := json.Marshal(fileStoreRecord{Op: "put", Metadata: metadata})
+		if err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to encode metadata record: %w", err)
+		}
+		writer.Write(append(line, '\n'))
+		compacted[metadata.BatchNumber] = metadata
+	}
+
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write compacted metadata file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync compacted metadata file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close compacted metadata file: %w", err)
+	}
+
+	// Open the new log before renaming it so that a failure leaves the old
+	// log in place and still in use.
+	file, err := os.OpenFile(tmp.Name(), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open compacted metadata file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to replace metadata file: %w", err)
+	}
+	s.file.Close()
+	s.file = file
+	s.entries = compacted
+
+	return nil
+}
+
+// Flush syncs the log to disk. Every write is already synced, so this only
+// matters to callers that need an explicit durability point.
+func (s *FileMetadataStore) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Sync()
+}
+
+func (s *FileMetadataStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
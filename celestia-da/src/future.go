@@ -0,0 +1,76 @@
+package celestiada
+
+import "context"
+
+// Future holds the result of an operation running in the background. It is
+// resolved exactly once and can be read any number of times.
+type Future[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+func newFuture[T any]() *Future[T] {
+	return &Future[T]{done: make(chan struct{})}
+}
+
+func (f *Future[T]) resolve(value T, err error) {
+	f.value = value
+	f.err = err
+	close(f.done)
+}
+
+// Done returns a channel that is closed once the result is available.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Get waits for the result. If ctx is done first, it returns ctx.Err(); the
+// operation itself keeps running.
+func (f *Future[T]) Get(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// AsyncPublishBatch publishes data in a new goroutine and returns a Future
+// for its ref ID.
+func (p *Publisher) AsyncPublishBatch(ctx context.Context, data []byte) *Future[string] {
+	future := newFuture[string]()
+
+	go func() {
+		future.resolve(p.PublishBatch(ctx, data))
+	}()
+
+	return future
+}
+
+// WhenAll returns a Future that resolves once every future has, with their
+// results in argument order, or with the first error in argument order.
+func WhenAll(futures ...*Future[string]) *Future[[]string] {
+	all := newFuture[[]string]()
+
+	go func() {
+		results := make([]string, len(futures))
+		var firstErr error
+		for i, f := range futures {
+			<-f.done
+			if f.err != nil && firstErr == nil {
+				firstErr = f.err
+			}
+			results[i] = f.value
+		}
+
+		if firstErr != nil {
+			all.resolve(nil, firstErr)
+			return
+		}
+		all.resolve(results, nil)
+	}()
+
+	return all
+}
@@ -0,0 +1,134 @@
+package celestiada
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	gasCacheTTL   = time.Second
+	priceCacheTTL = 60 * time.Second
+)
+
+type gasTotals struct {
+	computedAt time.Time
+	batches    int
+	gasUsed    uint64
+	cost       float64
+	bytes      uint64
+}
+
+// cachedGasTotals scans the metadata store at most once per gasCacheTTL.
+func (c *CDKIntegration) cachedGasTotals() gasTotals {
+	c.gasMu.Lock()
+	defer c.gasMu.Unlock()
+
+	if !c.gasCache.computedAt.IsZero() && time.Since(c.gasCache.computedAt) < gasCacheTTL {
+		return c.gasCache
+	}
+
+	var totals gasTotals
+	c.metadataStore.Range(func(metadata *BatchMetadata) bool {
+		totals.batches++
+		totals.gasUsed += metadata.GasUsed
+		totals.cost += float64(metadata.GasUsed) * metadata.GasPrice
+		totals.bytes += metadata.Size
+		return true
+	})
+	totals.computedAt = time.Now()
+
+	c.gasCache = totals
+	return totals
+}
+
+// TotalGasSpent returns the estimated fees paid, in utia, for all stored
+// batches.
+func (c *CDKIntegration) TotalGasSpent() float64 {
+	return c.cachedGasTotals().cost
+}
+
+func (c *CDKIntegration) AverageGasPerBatch() float64 {
+	totals := c.cachedGasTotals()
+	if totals.batches == 0 {
+		return 0
+	}
+	return float64(totals.gasUsed) / float64(totals.batches)
+}
+
+// GasPerByteCost returns the fees paid per byte of batch data.
+func (c *CDKIntegration) GasPerByteCost() float64 {
+	totals := c.cachedGasTotals()
+	if totals.bytes == 0 {
+		return 0
+	}
+	return totals.cost / float64(totals.bytes)
+}
+
+type BatchCostSummary struct {
+	BatchNumber   uint64
+	DataSizeBytes uint64
+	ShareCount    int
+	GasUsed       uint64
+	GasPrice      float64
+	CostUTIA      float64
+	CostUSD       float64
+}
+
+// BatchCostSummary breaks down the estimated fees paid for one batch.
+// CostUSD is only filled in when Config.UTIAUSDPriceFeed is set; the price
+// is fetched at most once per priceCacheTTL. Batches on the fallback DA
+// layer occupy no shares and cost nothing on Celestia.
+func (c *CDKIntegration) BatchCostSummary(batchNumber uint64) (*BatchCostSummary, error) {
+	metadata, err := c.GetBatchMetadata(batchNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &BatchCostSummary{
+		BatchNumber:   metadata.BatchNumber,
+		DataSizeBytes: metadata.Size,
+		GasUsed:       metadata.GasUsed,
+		GasPrice:      metadata.GasPrice,
+		CostUTIA:      float64(metadata.GasUsed) * metadata.GasPrice,
+	}
+
+	if metadata.DALayer != DALayerFallback && metadata.Size > 0 {
+		published := metadata.Size
+		if metadata.CompressedSize > 0 {
+			published = metadata.CompressedSize
+		}
+		shares, err := sparseSharesNeeded(int(published), metadata.ShareVersion)
+		if err != nil {
+			return nil, fmt.Errorf("batch %d: %w", batchNumber, err)
+		}
+		summary.ShareCount = shares
+	}
+
+	if c.config.UTIAUSDPriceFeed != nil {
+		price, err := c.utiaUSDPrice()
+		if err != nil {
+			return nil, err
+		}
+		summary.CostUSD = summary.CostUTIA * price
+	}
+
+	return summary, nil
+}
+
+func (c *CDKIntegration) utiaUSDPrice() (float64, error) {
+	c.priceMu.Lock()
+	defer c.priceMu.Unlock()
+
+	if !c.usdPriceAt.IsZero() && time.Since(c.usdPriceAt) < priceCacheTTL {
+		return c.usdPrice, nil
+	}
+
+	price, err := c.config.UTIAUSDPriceFeed(c.ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get uTIA price: %w", err)
+	}
+
+	c.usdPrice = price
+	c.usdPriceAt = time.Now()
+	return price, nil
+}
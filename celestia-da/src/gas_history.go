@@ -0,0 +1,69 @@
+package celestiada
+
+import "time"
+
+const gasHistorySize = 1000
+
+type GasRecord struct {
+	Timestamp time.Time
+	BatchSize uint64
+	GasPrice  float64
+	GasUsed   uint64
+}
+
+// recordGas adds a successful submission of data, paid at gasPrice, to the
+// gas history.
+func (p *Publisher) recordGas(data []byte, gasPrice float64) {
+	record := GasRecord{
+		Timestamp: time.Now(),
+		BatchSize: uint64(len(data)),
+		GasPrice:  gasPrice,
+		GasUsed:   estimateDataGas(data),
+	}
+
+	p.gasHistoryMu.Lock()
+	defer p.gasHistoryMu.Unlock()
+
+	if len(p.gasHistory) < gasHistorySize {
+		p.gasHistory = append(p.gasHistory, record)
+		return
+	}
+	p.gasHistory[p.gasHistoryNext] = record
+	p.gasHistoryNext = (p.gasHistoryNext + 1) % len(p.gasHistory)
+}
+
+// GasHistory returns the last gasHistorySize successful submissions, oldest
+// first. Blobs sent together by SubmitBlobs get one record each. Gas used is
+// estimated from the blob size, as in DryRunEstimate.
+func (p *Publisher) GasHistory() []GasRecord {
+	p.gasHistoryMu.Lock()
+	defer p.gasHistoryMu.Unlock()
+
+	history := make([]GasRecord, 0, len(p.gasHistory))
+	history = append(history, p.gasHistory[p.gasHistoryNext:]...)
+	history = append(history, p.gasHistory[:p.gasHistoryNext]...)
+	return history
+}
+
+// GasMovingAverage returns the mean gas price over the last window records,
+// or over all records if there are fewer. It returns 0 when there is no
+// history or window is not positive.
+func (p *Publisher) GasMovingAverage(window int) float64 {
+	if window <= 0 {
+		return 0
+	}
+
+	history := p.GasHistory()
+	if window > len(history) {
+		window = len(history)
+	}
+	if window == 0 {
+		return 0
+	}
+
+	var total float64
+	for _, record := range history[len(history)-window:] {
+		total += record.GasPrice
+	}
+	return total / float64(window)
+}
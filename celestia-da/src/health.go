@@ -0,0 +1,98 @@
+package celestiada
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type HealthStatus struct {
+	Healthy        bool
+	CheckedAt      time.Time
+	Latency        time.Duration
+	NetworkHeight  uint64
+	UnhealthySince time.Time
+	Error          error
+}
+
+// HealthCheck fetches the network head and reports whether the node answered
+// within Config.SubmitTimeout. UnhealthySince is only filled in by the health
+// monitor, which tracks status across checks.
+func (p *Publisher) HealthCheck(ctx context.Context) HealthStatus {
+	ctx, cancel := context.WithTimeout(ctx, p.config.SubmitTimeout)
+	defer cancel()
+
+	start := time.Now()
+	height, err := p.networkHead(ctx)
+	status := HealthStatus{
+		CheckedAt: time.Now(),
+		Latency:   time.Since(start),
+	}
+	if err != nil {
+		status.Error = err
+		return status
+	}
+
+	status.Healthy = true
+	status.NetworkHeight = height
+	return status
+}
+
+// StartHealthMonitor runs HealthCheck every interval until ctx is done.
+// onUnhealthy, if not nil, is called when the node goes from healthy to
+// unhealthy, and again each time it has stayed unhealthy for another
+// Config.UnhealthyAlertRepeatInterval; with no repeat interval it is only
+// called on the transition. The latest result is available from IsHealthy.
+// It fails without starting the monitor if interval is not positive.
+func (p *Publisher) StartHealthMonitor(ctx context.Context, interval time.Duration, onUnhealthy func(HealthStatus)) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid health check interval: %v", interval)
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var unhealthySince, lastAlert time.Time
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+
+			status := p.HealthCheck(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			p.unhealthy.Store(!status.Healthy)
+
+			if status.Healthy {
+				unhealthySince = time.Time{}
+				continue
+			}
+
+			alert := false
+			if unhealthySince.IsZero() {
+				unhealthySince = status.CheckedAt
+				alert = true
+			} else if repeat := p.config.UnhealthyAlertRepeatInterval; repeat > 0 && status.CheckedAt.Sub(lastAlert) >= repeat {
+				alert = true
+			}
+			status.UnhealthySince = unhealthySince
+
+			if alert && onUnhealthy != nil {
+				lastAlert = status.CheckedAt
+				onUnhealthy(status)
+			}
+		}
+	}()
+	return nil
+}
+
+// IsHealthy reports the result of the latest health monitor check. It is true
+// until the monitor has seen a failure.
+func (p *Publisher) IsHealthy() bool {
+	return !p.unhealthy.Load()
+}
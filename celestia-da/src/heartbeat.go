@@ -0,0 +1,67 @@
+package celestiada
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// HeartbeatBatchNumber is the BatchNumber of the BatchFailure reported to
+// WatchBatchFailures when a heartbeat ping fails.
+const HeartbeatBatchNumber = math.MaxUint64
+
+// Ping checks that the Celestia node is reachable. It runs the same probe as
+// HealthCheck and returns its error.
+func (p *Publisher) Ping(ctx context.Context) error {
+	if status := p.HealthCheck(ctx); !status.Healthy {
+		return fmt.Errorf("failed to ping node: %w", status.Error)
+	}
+	return nil
+}
+
+// Healthy reports whether the last heartbeat ping succeeded. It is always
+// true when Config.HeartbeatInterval is not set.
+func (c *CDKIntegration) Healthy() bool {
+	return !c.unhealthy.Load()
+}
+
+// runHeartbeat pings the node every Config.HeartbeatInterval while no batches
+// are pending, so a broken connection is noticed before the next submission.
+// Each failed ping is reported to WatchBatchFailures under
+// HeartbeatBatchNumber.
+func (c *CDKIntegration) runHeartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.ctx.Done():
+			return
+		}
+
+		c.pendingMu.Lock()
+		idle := c.pendingCount == 0
+		c.pendingMu.Unlock()
+		if !idle {
+			continue
+		}
+
+		err := c.publisher.Ping(c.ctx)
+		if c.ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			c.unhealthy.Store(false)
+			continue
+		}
+
+		c.unhealthy.Store(true)
+		c.broadcastFailure(BatchFailure{
+			BatchNumber: HeartbeatBatchNumber,
+			Error:       err,
+			QueuedAt:    time.Now(),
+		})
+	}
+}
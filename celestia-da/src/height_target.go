@@ -0,0 +1,74 @@
+package celestiada
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	heightPollMinInterval = 100 * time.Millisecond
+	heightPollMaxInterval = 2 * time.Second
+)
+
+// SubmitBlobsAtHeight waits until the network head is targetHeight-1 and then
+// publishes data, so it is included at targetHeight if the next block has
+// room for it. Polling backs off from heightPollMinInterval to
+// heightPollMaxInterval. ErrHeightMissed is returned if the head passes the
+// target before submission; if the blob is submitted but lands at another
+// height, its ref ID is returned along with ErrHeightMissed. The wait is
+// bounded by Config.HeightTargetingTimeout when set.
+func (p *Publisher) SubmitBlobsAtHeight(ctx context.Context, targetHeight uint64, data []byte) (string, error) {
+	if targetHeight == 0 {
+		return "", fmt.Errorf("invalid target height: 0")
+	}
+
+	waitCtx := ctx
+	if timeout := p.config.HeightTargetingTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	interval := heightPollMinInterval
+	for {
+		rpcStart := time.Now()
+		head, err := p.client.Header.NetworkHead(waitCtx)
+		p.traceRPC("Header.NetworkHead", rpcStart, err)
+		if err != nil {
+			return "", fmt.Errorf("failed to get network head: %w", err)
+		}
+
+		height := head.Height()
+		p.recordTipHeight(height)
+		if height >= targetHeight {
+			return "", fmt.Errorf("%w: head is at %d, target was %d", ErrHeightMissed, height, targetHeight)
+		}
+		if height == targetHeight-1 {
+			break
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-waitCtx.Done():
+			return "", fmt.Errorf("waiting for height %d (head at %d): %w", targetHeight-1, height, waitCtx.Err())
+		}
+		if interval *= 2; interval > heightPollMaxInterval {
+			interval = heightPollMaxInterval
+		}
+	}
+
+	refID, err := p.PublishBatch(ctx, data)
+	if err != nil {
+		return "", err
+	}
+
+	height, _, err := parseRefID(refID)
+	if err != nil {
+		return "", err
+	}
+	if height != targetHeight {
+		return refID, fmt.Errorf("%w: included at %d, target was %d", ErrHeightMissed, height, targetHeight)
+	}
+	return refID, nil
+}
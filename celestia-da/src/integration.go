@@ -2,35 +2,146 @@ package celestiada
 
 import (
 	"context"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/share"
 )
 
 type BatchMetadata struct {
-	BatchNumber    uint64    `json:"batchNumber"`
-	StateRoot      string    `json:"stateRoot"`
-	Timestamp      time.Time `json:"timestamp"`
-	TxCount        int       `json:"txCount"`
-	CelestiaHeight uint64    `json:"celestiaHeight"`
-	Commitment     string    `json:"commitment"`
+	BatchNumber    uint64            `json:"batchNumber"`
+	StateRoot      string            `json:"stateRoot"`
+	Timestamp      time.Time         `json:"timestamp"`
+	TxCount        int               `json:"txCount"`
+	CelestiaHeight uint64            `json:"celestiaHeight"`
+	Commitment     string            `json:"commitment"`
+	RefID          string            `json:"refId"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	Size           uint64            `json:"size"`
+	CompressedSize uint64            `json:"compressedSize,omitempty"`
+	Compression    string            `json:"compression,omitempty"`
+	GasUsed        uint64            `json:"gasUsed"`
+	GasPrice       float64           `json:"gasPrice"`
+	DALayer        string            `json:"daLayer,omitempty"`
+	SchemaVersion  uint8             `json:"schemaVersion,omitempty"`
+	Namespace      string            `json:"namespace,omitempty"`
+	ShareVersion   uint8             `json:"shareVersion"`
 }
 
 type CDKIntegration struct {
-	publisher      *Publisher
-	metadataStore  sync.Map
-	batchQueue     chan *BatchData
+	config         Config
+	publisher      batchPublisher
+	metadataStore  MetadataStore
+	metadataCache  *cachedMetadataStore
+	queueMu        sync.RWMutex
+	batchQueue     atomic.Pointer[batchQueue]
+	pendingMu      sync.Mutex
+	pendingCount   int
+	pendingSet     map[uint64]*BatchData
+	drained        chan struct{}
+	orderMu        sync.Mutex
+	inFlight       map[uint64]*sync.Cond
+	lastConfirmed  atomic.Uint64
+	workerMu       sync.Mutex
+	workers        []chan struct{}
+	workersDone    sync.WaitGroup
+	abandoned      atomic.Int64
+	drainedOK      atomic.Int64
+	drainFailed    atomic.Int64
+	tailMu         sync.RWMutex
+	recentBatches  []*BatchMetadata
+	recentNext     int
+	processingMu   sync.RWMutex
+	suspendMu      sync.Mutex
+	suspended      bool
+	suspendHolds   int
+	compactMu      sync.Mutex
+	lastCompacted  time.Time
+	compacting     atomic.Bool
+	sinceCompact   atomic.Int64
+	sinceEviction  atomic.Int64
+	evicting       atomic.Bool
+	latencyMu      sync.Mutex
+	latencies      []time.Duration
+	latencyNext    int
+	published      atomic.Int64
+	failed         atomic.Int64
+	gasMu          sync.Mutex
+	gasCache       gasTotals
+	failedBatches  sync.Map
+	validatorsMu   sync.RWMutex
+	validators     []BatchValidator
+	metricsMu      sync.RWMutex
+	metrics        MetricsRecorder
+	latestBatch    atomic.Uint64
+	batchTimeout   atomic.Int64
+	fallbacks      atomic.Int64
+	failureMu      sync.Mutex
+	failureSubs    map[chan BatchFailure]struct{}
+	snapshotMu     sync.RWMutex
+	unhealthy      atomic.Bool
+	rateMu         sync.RWMutex
+	completions    []time.Time
+	completionNext int
+	deserializerMu sync.RWMutex
+	deserializer   BatchDeserializer
+	priceMu        sync.Mutex
+	usdPrice       float64
+	usdPriceAt     time.Time
+	stopping       atomic.Bool
+	ownedStore     *FileMetadataStore
+	statsMu        sync.Mutex
+	statsCache     BatchMetadataStats
+	statsAt        time.Time
+	coalesceMu     sync.Mutex
+	coalesced      map[uint64][]chan PublishResult
+	metadataSubsMu sync.Mutex
+	metadataSubs   map[chan *BatchMetadata]func(*BatchMetadata) bool
+	notifications  chan *BatchMetadata
+	quorum         *quorumPublisher
+	listenersMu    sync.RWMutex
+	listeners      map[string]BatchListener
+	parent         *CDKIntegration
+	highPriority   atomic.Int64
+	auditLog       *auditLog
+	step           batchStepper
 	ctx            context.Context
 	cancel         context.CancelFunc
 }
 
+type batchQueue struct {
+	batches chan *BatchData
+	retired chan struct{}
+}
+
+func newBatchQueue(capacity int) *batchQueue {
+	return &batchQueue{
+		batches: make(chan *BatchData, capacity),
+		retired: make(chan struct{}),
+	}
+}
+
 type BatchData struct {
-	Number      uint64
-	Data        []byte
-	StateRoot   string
-	TxCount     int
-	ResultChan  chan PublishResult
+	Number          uint64
+	Data            []byte
+	StateRoot       string
+	TxCount         int
+	Labels          map[string]string
+	ResultChan      chan PublishResult
+	TimeoutOverride time.Duration
+	Deadline        time.Time
+	MaxFeeUTIA      uint64
+	UseHighPriority bool
+	queuedAt        time.Time
 }
 
 type PublishResult struct {
@@ -46,69 +157,901 @@ func NewCDKIntegration(config Config) (*CDKIntegration, error) {
 		return nil, err
 	}
 
+	integration, err := newCDKIntegration(config, publisher)
+	if err != nil {
+		publisher.Close()
+		return nil, err
+	}
+	return integration, nil
+}
+
+// newCDKIntegration builds an integration around an existing publisher. On
+// error the publisher is left open for the caller to close.
+func newCDKIntegration(config Config, publisher batchPublisher) (*CDKIntegration, error) {
+	store := config.MetadataStore
+	if store == nil {
+		store = NewMemoryMetadataStore()
+	}
+
+	var cache *cachedMetadataStore
+	if config.MetadataCacheSize > 0 {
+		cache = newCachedMetadataStore(store, config.MetadataCacheSize)
+		store = cache
+	}
+
+	tailSize := config.TailBufferSize
+	if tailSize <= 0 {
+		tailSize = defaultTailBufferSize
+	}
+
+	if config.BatchQueueTimeout < 0 {
+		return nil, fmt.Errorf("invalid batch queue timeout: %v", config.BatchQueueTimeout)
+	}
+	if !validCompression(config.Compression) {
+		return nil, fmt.Errorf("unsupported compression codec %q", config.Compression)
+	}
+
+	var audit *auditLog
+	if config.AuditLogPath != "" {
+		var err error
+		if audit, err = openAuditLog(config.AuditLogPath); err != nil {
+			return nil, err
+		}
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	
 	integration := &CDKIntegration{
-		publisher:  publisher,
-		batchQueue: make(chan *BatchData, 100),
-		ctx:        ctx,
-		cancel:     cancel,
+		config:        config,
+		publisher:     publisher,
+		metadataStore: store,
+		metadataCache: cache,
+		pendingSet:    make(map[uint64]*BatchData),
+		drained:       make(chan struct{}),
+		inFlight:      make(map[uint64]*sync.Cond),
+		failureSubs:   make(map[chan BatchFailure]struct{}),
+		coalesced:     make(map[uint64][]chan PublishResult),
+		metadataSubs:  make(map[chan *BatchMetadata]func(*BatchMetadata) bool),
+		notifications: make(chan *BatchMetadata, metadataNotifyBuffer),
+		listeners:     make(map[string]BatchListener),
+		recentBatches: make([]*BatchMetadata, 0, tailSize),
+		latencies:     make([]time.Duration, 0, latencySampleSize),
+		completions:   make([]time.Time, 0, completionSampleSize),
+		auditLog:      audit,
+		ctx:           ctx,
+		cancel:        cancel,
 	}
+	close(integration.drained)
+	integration.batchTimeout.Store(int64(config.BatchQueueTimeout))
 
-	go integration.processBatches()
+	err := store.Range(func(metadata *BatchMetadata) bool {
+		integration.updateLatest(metadata.BatchNumber)
+		return true
+	})
+	if err != nil {
+		cancel()
+		integration.closeAuditLog()
+		return nil, fmt.Errorf("failed to read metadata store: %w", err)
+	}
+	integration.batchQueue.Store(newBatchQueue(100))
+
+	workerCount := config.WorkerCount
+	if workerCount <= 0 {
+		workerCount = 1
+	}
+	if err := integration.ResizeWorkerPool(workerCount); err != nil {
+		cancel()
+		integration.closeAuditLog()
+		return nil, err
+	}
+	integration.goTracked(integration.dispatchMetadata)
+
+	if config.HeartbeatInterval > 0 {
+		go integration.runHeartbeat(config.HeartbeatInterval)
+	}
 	
 	return integration, nil
 }
 
 func (c *CDKIntegration) SubmitBatch(batchNumber uint64, data []byte, stateRoot string, txCount int) <-chan PublishResult {
-	resultChan := make(chan PublishResult, 1)
+	return c.SubmitBatchWithLabels(batchNumber, data, stateRoot, txCount, nil)
+}
+
+// SubmitBatchWithLabels queues a batch carrying caller-defined labels. Labels
+// are copied into the batch metadata and logs; they never affect submission.
+func (c *CDKIntegration) SubmitBatchWithLabels(batchNumber uint64, data []byte, stateRoot string, txCount int, labels map[string]string) <-chan PublishResult {
+	return c.enqueue(&BatchData{
+		Number:     batchNumber,
+		Data:       data,
+		StateRoot:  stateRoot,
+		TxCount:    txCount,
+		Labels:     copyLabels(labels),
+		ResultChan: make(chan PublishResult, 1),
+	})
+}
+
+func (c *CDKIntegration) enqueue(batch *BatchData) <-chan PublishResult {
+	resultChan := batch.ResultChan
+
+	if err := c.tryEnqueue(context.Background(), batch, -1); err != nil {
+		resultChan <- PublishResult{
+			Success: false,
+			Error:   err,
+		}
+	}
 	
+	return resultChan
+}
+
+// SubmitBatchOrBlock queues a batch, waiting at most maxWait for queue space
+// before failing with ErrQueueFull. A maxWait of 0 never waits, and a negative
+// maxWait waits until there is space, ctx is done or the integration shuts
+// down. Unlike SubmitBatch, a batch that could not be queued is reported
+// through the error rather than the result channel.
+func (c *CDKIntegration) SubmitBatchOrBlock(ctx context.Context, batchNumber uint64, data []byte, stateRoot string, txCount int, maxWait time.Duration) (<-chan PublishResult, error) {
 	batch := &BatchData{
 		Number:     batchNumber,
 		Data:       data,
 		StateRoot:  stateRoot,
 		TxCount:    txCount,
-		ResultChan: resultChan,
+		ResultChan: make(chan PublishResult, 1),
 	}
-	
-	select {
-	case c.batchQueue <- batch:
-	case <-c.ctx.Done():
+
+	if err := c.tryEnqueue(ctx, batch, maxWait); err != nil {
+		return nil, err
+	}
+	return batch.ResultChan, nil
+}
+
+// SubmitBatchWithMetadata records metadata for a batch whose blob was
+// submitted by other means, such as an external coordinator or a migration,
+// without publishing anything. metadata must describe batch and carry its
+// Celestia location; RefID and DALayer are derived when left empty. When
+// metadata is nil or its CelestiaHeight is zero, the batch is queued for
+// publishing like SubmitBatch.
+func (c *CDKIntegration) SubmitBatchWithMetadata(ctx context.Context, batch *BatchData, metadata *BatchMetadata) <-chan PublishResult {
+	if batch.ResultChan == nil {
+		batch.ResultChan = make(chan PublishResult, 1)
+	}
+	resultChan := batch.ResultChan
+
+	if metadata == nil || metadata.CelestiaHeight == 0 {
+		if err := c.tryEnqueue(ctx, batch, -1); err != nil {
+			resultChan <- PublishResult{
+				Success: false,
+				Error:   err,
+			}
+		}
+		return resultChan
+	}
+
+	stored, err := c.storeExternalMetadata(batch, metadata)
+	c.audit(ctx, AuditOpSubmit, batch.Number, err)
+	if err != nil {
 		resultChan <- PublishResult{
 			Success: false,
-			Error:   fmt.Errorf("CDK integration is shutting down"),
+			Error:   err,
 		}
+		return resultChan
+	}
+
+	resultChan <- PublishResult{
+		Success:  true,
+		RefID:    stored.RefID,
+		Metadata: stored,
 	}
-	
 	return resultChan
 }
 
-func (c *CDKIntegration) processBatches() {
+func (c *CDKIntegration) storeExternalMetadata(batch *BatchData, metadata *BatchMetadata) (*BatchMetadata, error) {
+	if c.stopping.Load() {
+		return nil, fmt.Errorf("CDK integration is shutting down")
+	}
+	if metadata.BatchNumber != batch.Number {
+		return nil, fmt.Errorf("metadata is for batch %d, not batch %d", metadata.BatchNumber, batch.Number)
+	}
+	if _, err := hex.DecodeString(metadata.Commitment); err != nil || metadata.Commitment == "" {
+		return nil, fmt.Errorf("batch %d: invalid commitment %q", batch.Number, metadata.Commitment)
+	}
+
+	stored := *metadata
+	stored.Labels = copyLabels(metadata.Labels)
+	if stored.RefID == "" {
+		stored.RefID = fmt.Sprintf("%d:%s", stored.CelestiaHeight, stored.Commitment)
+	}
+	if stored.DALayer == "" {
+		stored.DALayer = DALayerCelestia
+	}
+	if stored.Timestamp.IsZero() {
+		stored.Timestamp = time.Now()
+	}
+
+	migrated, err := migrateMetadata(&stored)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.storeMetadata(migrated); err != nil {
+		return nil, fmt.Errorf("failed to store metadata for batch %d: %w", batch.Number, err)
+	}
+	c.notifyMetadata(migrated)
+	return migrated, nil
+}
+
+// SubmitBatchWithDeadline queues a batch that is dropped with
+// ErrDeadlineExceeded if no worker has picked it up by deadline. Unlike
+// BatchQueueTimeout, which limits how long publishing may take once a batch
+// is dequeued, deadline is a wall-clock time checked before publishing
+// starts; a batch already being published is not interrupted.
+func (c *CDKIntegration) SubmitBatchWithDeadline(ctx context.Context, batchNumber uint64, data []byte, stateRoot string, txCount int, deadline time.Time) <-chan PublishResult {
+	batch := &BatchData{
+		Number:     batchNumber,
+		Data:       data,
+		StateRoot:  stateRoot,
+		TxCount:    txCount,
+		Deadline:   deadline,
+		ResultChan: make(chan PublishResult, 1),
+	}
+
+	if err := c.tryEnqueue(ctx, batch, -1); err != nil {
+		batch.ResultChan <- PublishResult{
+			Success: false,
+			Error:   err,
+		}
+	}
+	return batch.ResultChan
+}
+
+// SubmitBatchWithFeeLimit queues a batch that is only published if its
+// estimated fee is at most maxFeeUTIA. The fee is estimated at the price the
+// batch is then submitted at: Config.GasPrice or, if that is not set, the
+// gas price the node estimates when the batch is published. A batch over the
+// limit fails with an error wrapping ErrFeeLimitExceeded without being
+// submitted, and is not retried or kept for ResubmitFailed.
+func (c *CDKIntegration) SubmitBatchWithFeeLimit(ctx context.Context, batchNumber uint64, data []byte, stateRoot string, txCount int, maxFeeUTIA uint64) <-chan PublishResult {
+	batch := &BatchData{
+		Number:     batchNumber,
+		Data:       data,
+		StateRoot:  stateRoot,
+		TxCount:    txCount,
+		MaxFeeUTIA: maxFeeUTIA,
+		ResultChan: make(chan PublishResult, 1),
+	}
+
+	if err := c.tryEnqueue(ctx, batch, -1); err != nil {
+		batch.ResultChan <- PublishResult{
+			Success: false,
+			Error:   err,
+		}
+	}
+	return batch.ResultChan
+}
+
+// SubmitBatchWithPriority queues a time-critical batch that pays the
+// priority gas price (Config.GasPrice * Config.PriorityGasMultiplier) for
+// faster inclusion. A non-zero deadline drops the batch with
+// ErrDeadlineExceeded if no worker has picked it up by then, as with
+// SubmitBatchWithDeadline.
+func (c *CDKIntegration) SubmitBatchWithPriority(ctx context.Context, batchNumber uint64, data []byte, stateRoot string, txCount int, deadline time.Time) <-chan PublishResult {
+	batch := &BatchData{
+		Number:          batchNumber,
+		Data:            data,
+		StateRoot:       stateRoot,
+		TxCount:         txCount,
+		Deadline:        deadline,
+		UseHighPriority: true,
+		ResultChan:      make(chan PublishResult, 1),
+	}
+
+	if err := c.tryEnqueue(ctx, batch, -1); err != nil {
+		batch.ResultChan <- PublishResult{
+			Success: false,
+			Error:   err,
+		}
+	}
+	return batch.ResultChan
+}
+
+func (c *CDKIntegration) tryEnqueue(ctx context.Context, batch *BatchData, maxWait time.Duration) (err error) {
+	defer func() { c.audit(ctx, AuditOpSubmit, batch.Number, err) }()
+	batch.queuedAt = time.Now()
+
+	c.queueMu.RLock()
+	defer c.queueMu.RUnlock()
+
+	if c.stopping.Load() {
+		return fmt.Errorf("CDK integration is shutting down")
+	}
+
+	c.addPending(batch)
+	if err := c.sendBatch(ctx, c.batchQueue.Load().batches, batch, maxWait); err != nil {
+		c.removePending(batch)
+		c.donePending()
+		return err
+	}
+	return nil
+}
+
+func (c *CDKIntegration) sendBatch(ctx context.Context, queue chan<- *BatchData, batch *BatchData, maxWait time.Duration) error {
+	if maxWait == 0 {
+		select {
+		case queue <- batch:
+			return nil
+		default:
+			return ErrQueueFull
+		}
+	}
+
+	var timeout <-chan time.Time
+	if maxWait > 0 {
+		timer := time.NewTimer(maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case queue <- batch:
+		return nil
+	case <-timeout:
+		return ErrQueueFull
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.ctx.Done():
+		return fmt.Errorf("CDK integration is shutting down")
+	}
+}
+
+func (c *CDKIntegration) processBatches(stop <-chan struct{}) {
 	for {
 		select {
-		case batch := <-c.batchQueue:
-			c.processBatch(batch)
+		case <-stop:
+			return
+		default:
+		}
+
+		queue := c.batchQueue.Load()
+		select {
+		case batch, ok := <-queue.batches:
+			if !ok {
+				return
+			}
+			c.removePending(batch)
+			c.processingMu.RLock()
+			var result PublishResult
+			if c.ctx.Err() != nil {
+				c.abandoned.Add(1)
+				result = PublishResult{
+					Success: false,
+					Error:   fmt.Errorf("CDK integration is shutting down"),
+				}
+				batch.ResultChan <- result
+			} else {
+				result = c.processBatch(batch)
+				c.countDrained(result)
+			}
+			c.processingMu.RUnlock()
+			c.donePending()
+			c.afterBatch(result.Metadata, stop)
+		case <-queue.retired:
+		case <-stop:
+			return
 		case <-c.ctx.Done():
 			return
 		}
 	}
 }
 
-func (c *CDKIntegration) processBatch(batch *BatchData) {
+// ResizeWorkerPool grows or shrinks the number of workers draining the batch
+// queue. Workers removed by a shrink exit after finishing their current batch.
+func (c *CDKIntegration) ResizeWorkerPool(newCount int) error {
+	if newCount <= 0 {
+		return fmt.Errorf("invalid worker count: %d", newCount)
+	}
+
+	c.workerMu.Lock()
+	defer c.workerMu.Unlock()
+
+	if c.ctx.Err() != nil {
+		return fmt.Errorf("CDK integration is shutting down")
+	}
+
+	for len(c.workers) < newCount {
+		stop := make(chan struct{})
+		c.workers = append(c.workers, stop)
+		c.workersDone.Add(1)
+		go func() {
+			defer c.workersDone.Done()
+			c.processBatches(stop)
+		}()
+	}
+
+	for len(c.workers) > newCount {
+		last := len(c.workers) - 1
+		close(c.workers[last])
+		c.workers = c.workers[:last]
+	}
+
+	return nil
+}
+
+// stopWorkers cancels the integration's context and waits for every worker
+// and goTracked goroutine to return, so that nothing is still writing to the
+// store, publisher or audit log when they are closed. Suspended processing is
+// resumed so that workers waiting on it can see the cancellation.
+func (c *CDKIntegration) stopWorkers() {
+	c.workerMu.Lock()
+	c.cancel()
+	c.workerMu.Unlock()
+
+	c.ResumeProcessing()
+	c.workersDone.Wait()
+}
+
+// goTracked runs fn in a goroutine that stopWorkers waits for. It reports
+// false without running fn once the integration is shutting down.
+func (c *CDKIntegration) goTracked(fn func()) bool {
+	c.workerMu.Lock()
+	defer c.workerMu.Unlock()
+
+	if c.ctx.Err() != nil {
+		return false
+	}
+	c.workersDone.Add(1)
+	go func() {
+		defer c.workersDone.Done()
+		fn()
+	}()
+	return true
+}
+
+func (c *CDKIntegration) CurrentWorkerCount() int {
+	c.workerMu.Lock()
+	defer c.workerMu.Unlock()
+
+	return len(c.workers)
+}
+
+// SetQueueCapacity replaces the batch queue with one of the requested
+// capacity. Queued batches are moved to the new queue in order; any that no
+// longer fit are failed on their ResultChan and counted in dropped.
+func (c *CDKIntegration) SetQueueCapacity(newCapacity int) (dropped int, err error) {
+	if newCapacity <= 0 {
+		return 0, fmt.Errorf("invalid queue capacity: %d", newCapacity)
+	}
+
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+
+	if c.ctx.Err() != nil {
+		return 0, fmt.Errorf("CDK integration is shutting down")
+	}
+
+	oldQueue := c.batchQueue.Load()
+	newQueue := newBatchQueue(newCapacity)
+
+	for drained := false; !drained; {
+		select {
+		case batch := <-oldQueue.batches:
+			if len(newQueue.batches) < newCapacity {
+				newQueue.batches <- batch
+				continue
+			}
+			batch.ResultChan <- PublishResult{
+				Success: false,
+				Error:   fmt.Errorf("batch %d dropped: queue resized to %d", batch.Number, newCapacity),
+			}
+			c.removePending(batch)
+			c.donePending()
+			dropped++
+		default:
+			drained = true
+		}
+	}
+
+	c.batchQueue.Store(newQueue)
+	close(oldQueue.retired)
+
+	return dropped, nil
+}
+
+// CancelBatch removes a queued batch before a worker picks it up and fails
+// it with ErrBatchCancelled. The queue is drained and every other batch is
+// re-enqueued in order, so the cost is O(n) in the queue length and
+// submissions block while it runs.
+func (c *CDKIntegration) CancelBatch(batchNumber uint64) error {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+
+	c.pendingMu.Lock()
+	target, ok := c.pendingSet[batchNumber]
+	c.pendingMu.Unlock()
+	if !ok {
+		return fmt.Errorf("batch %d: %w", batchNumber, ErrBatchNotFound)
+	}
+
+	queue := c.batchQueue.Load()
+
+	var queued []*BatchData
+	for drained := false; !drained; {
+		select {
+		case batch := <-queue.batches:
+			queued = append(queued, batch)
+		default:
+			drained = true
+		}
+	}
+
+	found := false
+	for _, batch := range queued {
+		if batch == target && !found {
+			found = true
+			continue
+		}
+		queue.batches <- batch
+	}
+
+	if !found {
+		return fmt.Errorf("batch %d: %w", batchNumber, ErrBatchNotFound)
+	}
+
+	c.removePending(target)
+	c.donePending()
+
+	target.ResultChan <- PublishResult{
+		Success: false,
+		Error:   fmt.Errorf("batch %d: %w", batchNumber, ErrBatchCancelled),
+	}
+
+	return nil
+}
+
+// ResubmitFailed re-queues every batch whose most recent attempt failed with
+// an error a retry can fix. Batches rejected by a validator, by their fee
+// limit or for their size are not recorded, since they would only fail
+// again. Re-queued batches keep their original options, such as fee cap,
+// priority, timeout and deadline, but get fresh result channels that are not
+// returned; a batch that fails again is recorded as failed again and can be
+// resubmitted later.
+func (c *CDKIntegration) ResubmitFailed(ctx context.Context) (count int, errs []error) {
+	c.failedBatches.Range(func(key, value interface{}) bool {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			return false
+		}
+
+		failed := value.(*BatchData)
+		if c.ctx.Err() != nil {
+			errs = append(errs, fmt.Errorf("batch %d: CDK integration is shutting down", failed.Number))
+			return true
+		}
+
+		c.failedBatches.Delete(key)
+		retry := *failed
+		retry.ResultChan = make(chan PublishResult, 1)
+		c.enqueue(&retry)
+		count++
+		return true
+	})
+
+	return count, errs
+}
+
+// batchGasPrice returns the gas price batch is submitted at. Quorum
+// publishers submit at their own configured price, so high priority only
+// applies when the integration's own publisher submits. A batch with a fee
+// cap is priced with feeGasPrice, so that the cap is checked at a real price.
+func (c *CDKIntegration) batchGasPrice(ctx context.Context, batch *BatchData) (float64, error) {
+	highPriority := batch.UseHighPriority && c.quorum == nil
+	if batch.MaxFeeUTIA > 0 {
+		return c.publisher.feeGasPrice(ctx, highPriority)
+	}
+	return c.publisher.gasPrice(highPriority), nil
+}
+
+// permanentFailure reports whether err rejects the batch itself, so that
+// publishing it again cannot succeed.
+func permanentFailure(err error) bool {
+	var invalid ErrValidationFailed
+	return errors.As(err, &invalid) || errors.Is(err, ErrFeeLimitExceeded) || errors.Is(err, ErrBlobTooLarge)
+}
+
+// SuspendProcessing stops workers from starting new batches and waits for
+// batches already being processed to finish. Submissions are still queued.
+// Calls do not nest: one ResumeProcessing undoes any number of
+// SuspendProcessing calls. Internal operations that need processing stopped,
+// such as compaction and namespace rotation, hold it separately, so they
+// never resume processing the caller suspended and ResumeProcessing never
+// resumes it under them.
+func (c *CDKIntegration) SuspendProcessing() {
+	c.suspendMu.Lock()
+	defer c.suspendMu.Unlock()
+
+	c.setSuspension(true, c.suspendHolds)
+}
+
+func (c *CDKIntegration) ResumeProcessing() {
+	c.suspendMu.Lock()
+	defer c.suspendMu.Unlock()
+
+	c.setSuspension(false, c.suspendHolds)
+}
+
+// holdProcessing stops batch processing like SuspendProcessing until the
+// matching releaseProcessing. Holds nest and are independent of the public
+// suspended state.
+func (c *CDKIntegration) holdProcessing() {
+	c.suspendMu.Lock()
+	defer c.suspendMu.Unlock()
+
+	c.setSuspension(c.suspended, c.suspendHolds+1)
+}
+
+func (c *CDKIntegration) releaseProcessing() {
+	c.suspendMu.Lock()
+	defer c.suspendMu.Unlock()
+
+	c.setSuspension(c.suspended, c.suspendHolds-1)
+}
+
+// setSuspension updates the suspended state and hold count, taking
+// processingMu when processing becomes stopped and releasing it when neither
+// the caller nor any hold still needs it stopped. suspendMu must be held.
+func (c *CDKIntegration) setSuspension(suspended bool, holds int) {
+	wasStopped := c.suspended || c.suspendHolds > 0
+	isStopped := suspended || holds > 0
+	switch {
+	case isStopped && !wasStopped:
+		c.processingMu.Lock()
+	case wasStopped && !isStopped:
+		c.processingMu.Unlock()
+	}
+	c.suspended = suspended
+	c.suspendHolds = holds
+}
+
+func (c *CDKIntegration) addPending(batch *BatchData) {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+
+	if c.pendingCount == 0 {
+		c.drained = make(chan struct{})
+	}
+	c.pendingCount++
+	c.pendingSet[batch.Number] = batch
+}
+
+func (c *CDKIntegration) removePending(batch *BatchData) {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+
+	if c.pendingSet[batch.Number] == batch {
+		delete(c.pendingSet, batch.Number)
+	}
+}
+
+func (c *CDKIntegration) donePending() {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+
+	c.pendingCount--
+	if c.pendingCount == 0 {
+		close(c.drained)
+	}
+}
+
+// FlushQueue blocks until the queue is empty and no batch is being processed.
+func (c *CDKIntegration) FlushQueue(ctx context.Context) error {
+	c.pendingMu.Lock()
+	drained := c.drained
+	c.pendingMu.Unlock()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *CDKIntegration) processBatch(batch *BatchData) PublishResult {
+	if c.config.StrictOrdering {
+		c.trackOrder(batch.Number)
+	}
+
+	ctx := c.ctx
+	if timeout := time.Duration(c.batchTimeout.Load()); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var result PublishResult
+	if !batch.Deadline.IsZero() && time.Now().After(batch.Deadline) {
+		result = PublishResult{
+			Success: false,
+			Error:   fmt.Errorf("batch %d: %w", batch.Number, ErrDeadlineExceeded),
+		}
+	} else {
+		start := time.Now()
+		result = c.publishBatch(ctx, batch)
+		duration := time.Since(start)
+		c.recordResult(result, duration)
+		c.recordMetrics(duration, result.Success, batch.Number)
+		c.maybeEvict()
+
+		if result.Success || permanentFailure(result.Error) {
+			c.failedBatches.Delete(batch.Number)
+		} else {
+			c.failedBatches.Store(batch.Number, batch)
+		}
+	}
+
+	if c.config.StrictOrdering {
+		c.awaitTurn(batch.Number)
+		defer c.confirmOrder(batch.Number)
+	}
+	if result.Success {
+		c.notifyMetadata(result.Metadata)
+	}
+
+	batch.ResultChan <- result
+	return result
+}
+
+// SetBatchQueueTimeout changes the time a batch may spend publishing, retries
+// included, once a worker has dequeued it. It takes effect from the next
+// dequeued batch; batches already being published keep their old deadline.
+// Zero disables the timeout.
+func (c *CDKIntegration) SetBatchQueueTimeout(d time.Duration) error {
+	if d < 0 {
+		return fmt.Errorf("invalid batch queue timeout: %v", d)
+	}
+	c.batchTimeout.Store(int64(d))
+	return nil
+}
+
+// SetMaxBlobSize changes the blob size limit checked before each submission,
+// for example after a network upgrade raises the maximum square size. Batches
+// already rejected for size are not retried; callers must resubmit them.
+func (c *CDKIntegration) SetMaxBlobSize(newMax uint64) error {
+	if newMax == 0 {
+		return fmt.Errorf("invalid max blob size: %d", newMax)
+	}
+	c.publisher.setMaxBlobSize(newMax)
+	return nil
+}
+
+// submitWithRetry publishes the batch data, retrying up to Config.MaxRetries
+// times with exponential backoff from Config.RetryDelay. A positive
+// BatchData.TimeoutOverride replaces Config.SubmitTimeout for each attempt.
+// Once a blob has been submitted, later attempts only repeat the step that
+// failed after the submission, so a blob is never paid for twice. In quorum
+// mode a retry only goes to the publishers that have not yet published it.
+// Config.OnError is called from the worker goroutine after every failed
+// attempt, so it must not block.
+func (c *CDKIntegration) submitWithRetry(ctx context.Context, batch *BatchData, gasPrice float64) (refID string, attempts int, err error) {
+	var pending unconfirmedError
+	var quorum quorumAttempt
+	for retry := 0; ; retry++ {
+		switch {
+		case pending.refID != "":
+			refID = pending.refID
+			err = c.publisher.confirm(ctx, pending, c.config.DefaultConfirmations)
+		case c.quorum != nil:
+			refID, err = c.publishQuorum(ctx, batch, &quorum)
+		case c.config.DefaultConfirmations > 0:
+			refID, _, err = c.publisher.submitAndPoll(ctx, batch.Data, c.config.DefaultConfirmations, batch.TimeoutOverride, gasPrice)
+		default:
+			refID, err = c.publisher.publish(ctx, c.publisher.currentNamespace(), batch.Data, batch.TimeoutOverride, gasPrice)
+		}
+		if err == nil {
+			if batch.UseHighPriority && c.quorum == nil {
+				c.highPriority.Add(1)
+			}
+			return refID, retry + 1, nil
+		}
+
+		var unconfirmed unconfirmedError
+		if errors.As(err, &unconfirmed) {
+			pending = unconfirmed
+		}
+		if c.config.OnError != nil {
+			c.config.OnError(batch.Number, err, retry)
+		}
+
+		if retry >= c.config.MaxRetries || permanentFailure(err) {
+			return "", retry + 1, err
+		}
+
+		select {
+		case <-time.After(c.config.RetryDelay << retry):
+		case <-ctx.Done():
+			return "", retry + 1, err
+		}
+	}
+}
+
+func (c *CDKIntegration) publishBatch(ctx context.Context, batch *BatchData) PublishResult {
 	start := time.Now()
-	
-	refID, err := c.publisher.PublishBatch(c.ctx, batch.Data)
+
+	if err := c.validateBatch(batch); err != nil {
+		return PublishResult{
+			Success: false,
+			Error:   err,
+		}
+	}
+	payload, err := c.batchPayload(batch)
 	if err != nil {
-		batch.ResultChan <- PublishResult{
+		return PublishResult{
 			Success: false,
-			Error:   fmt.Errorf("failed to publish batch %d: %w", batch.Number, err),
+			Error:   err,
 		}
-		return
 	}
+	gasPrice, err := c.batchGasPrice(ctx, batch)
+	if err != nil {
+		return PublishResult{
+			Success: false,
+			Error:   fmt.Errorf("batch %d: %w", batch.Number, err),
+		}
+	}
+	if batch.MaxFeeUTIA > 0 {
+		if err := c.publisher.checkFeeLimit(payload.Data, batch.MaxFeeUTIA, gasPrice); err != nil {
+			return PublishResult{
+				Success: false,
+				Error:   fmt.Errorf("batch %d: %w", batch.Number, err),
+			}
+		}
+	}
+	
+	refID, attempts, err := c.submitWithRetry(ctx, payload, gasPrice)
+	if err != nil {
+		err = fmt.Errorf("failed to publish batch %d after %d attempts: %w", batch.Number, attempts, err)
+		if c.publisher.fallbackDA() == nil {
+			c.notifyFailure(batch, err, attempts)
+			return PublishResult{
+				Success: false,
+				Error:   err,
+			}
+		}
+
+		fbRefID, fbErr := c.publisher.submitFallback(ctx, payload.Data)
+		if fbErr != nil {
+			err = fmt.Errorf("%w (fallback: %v)", err, fbErr)
+			c.notifyFailure(batch, err, attempts)
+			return PublishResult{
+				Success: false,
+				Error:   err,
+			}
+		}
+
+		result := c.recordFallback(batch, payload.Data, fbRefID)
+		if result.Success {
+			fmt.Printf("Batch %d published to fallback DA in %v after Celestia failure: %v\n",
+				batch.Number, time.Since(start), err)
+		}
+		return result
+	}
+
+	result := c.recordPublished(batch, payload.Data, refID, gasPrice)
+	if result.Success {
+		duration := time.Since(start)
+		fmt.Printf("Batch %d published to Celestia in %v (height: %d, labels: %v)\n", 
+			batch.Number, duration, result.Metadata.CelestiaHeight, batch.Labels)
+	}
+
+	return result
+}
 
-	var height uint64
-	var commitment string
-	fmt.Sscanf(refID, "%d:%s", &height, &commitment)
+// recordPublished builds and stores the metadata for a batch that has been
+// included at refID, as payload, after paying gasPrice.
+func (c *CDKIntegration) recordPublished(batch *BatchData, payload []byte, refID string, gasPrice float64) PublishResult {
+	height, commitment, err := parseRefID(refID)
+	if err != nil {
+		return PublishResult{
+			Success: false,
+			RefID:   refID,
+			Error:   fmt.Errorf("failed to parse ref ID for batch %d: %w", batch.Number, err),
+		}
+	}
 	
 	metadata := &BatchMetadata{
 		BatchNumber:    batch.Number,
@@ -117,59 +1060,372 @@ func (c *CDKIntegration) processBatch(batch *BatchData) {
 		TxCount:        batch.TxCount,
 		CelestiaHeight: height,
 		Commitment:     commitment,
+		RefID:          refID,
+		Labels:         batch.Labels,
+		Size:           uint64(len(batch.Data)),
+		GasUsed:        estimateDataGas(payload),
+		GasPrice:       gasPrice,
+		DALayer:        DALayerCelestia,
+		SchemaVersion:  MetadataSchemaVersion,
+		Namespace:      c.publisher.Namespace(),
+		ShareVersion:   share.DefaultShareVersion,
 	}
+	c.recordCompression(metadata, payload)
 	
-	c.metadataStore.Store(batch.Number, metadata)
-	
-	batch.ResultChan <- PublishResult{
+	if err := c.storeMetadata(metadata); err != nil {
+		return PublishResult{
+			Success: false,
+			RefID:   refID,
+			Error:   fmt.Errorf("failed to store metadata for batch %d: %w", batch.Number, err),
+		}
+	}
+
+	return PublishResult{
 		Success:  true,
 		RefID:    refID,
 		Metadata: metadata,
 	}
+}
+
+// storeMetadata records metadata for a newly published batch. Writers share
+// snapshotMu so that only BatchMetadataSnapshot excludes them. Watchers and
+// listeners are not notified; callers do that with notifyMetadata once the
+// batch's turn has come, so that StrictOrdering holds for them too.
+func (c *CDKIntegration) storeMetadata(metadata *BatchMetadata) error {
+	c.snapshotMu.RLock()
+	err := c.metadataStore.Store(metadata)
+	c.snapshotMu.RUnlock()
+	if err != nil {
+		return err
+	}
 	
-	duration := time.Since(start)
-	fmt.Printf("Batch %d published to Celestia in %v (height: %d)\n", 
-		batch.Number, duration, height)
+	c.updateLatest(metadata.BatchNumber)
+	c.recordRecent(metadata)
+	c.maybeAutoCompact()
+	return nil
 }
 
 func (c *CDKIntegration) GetBatchMetadata(batchNumber uint64) (*BatchMetadata, error) {
-	value, ok := c.metadataStore.Load(batchNumber)
+	metadata, ok, err := c.metadataStore.Load(batchNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load metadata for batch %d: %w", batchNumber, err)
+	}
 	if !ok {
 		return nil, fmt.Errorf("metadata not found for batch %d", batchNumber)
 	}
 	
-	metadata, ok := value.(*BatchMetadata)
+	return migrateMetadata(metadata)
+}
+
+// DeleteBatchMetadata removes the stored metadata of a batch, for example
+// one recorded in error. It returns an error if there is none. The batch
+// itself stays on Celestia.
+func (c *CDKIntegration) DeleteBatchMetadata(ctx context.Context, batchNumber uint64) (err error) {
+	defer func() { c.audit(ctx, AuditOpDelete, batchNumber, err) }()
+
+	_, ok, err := c.metadataStore.Load(batchNumber)
+	if err != nil {
+		return fmt.Errorf("failed to load metadata for batch %d: %w", batchNumber, err)
+	}
 	if !ok {
-		return nil, fmt.Errorf("invalid metadata type for batch %d", batchNumber)
+		return fmt.Errorf("metadata not found for batch %d", batchNumber)
 	}
-	
-	return metadata, nil
+
+	c.snapshotMu.RLock()
+	err = c.metadataStore.Delete(batchNumber)
+	c.snapshotMu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to delete metadata for batch %d: %w", batchNumber, err)
+	}
+
+	c.forgetRecent(map[uint64]bool{batchNumber: true})
+	return c.recomputeLatest()
 }
 
-func (c *CDKIntegration) RetrieveBatchData(batchNumber uint64) ([]byte, error) {
+func (c *CDKIntegration) updateLatest(batchNumber uint64) {
+	for {
+		latest := c.latestBatch.Load()
+		if batchNumber <= latest || c.latestBatch.CompareAndSwap(latest, batchNumber) {
+			return
+		}
+	}
+}
+
+// recomputeLatest resets the latest batch pointer to the highest batch number
+// in the store. It is called after metadata has been removed, since
+// updateLatest only ever raises the pointer. A pointer raised concurrently to
+// a batch that is present in the store is left alone.
+func (c *CDKIntegration) recomputeLatest() error {
+	var highest uint64
+	err := c.metadataStore.Range(func(metadata *BatchMetadata) bool {
+		if metadata.BatchNumber > highest {
+			highest = metadata.BatchNumber
+		}
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read metadata store: %w", err)
+	}
+
+	for {
+		latest := c.latestBatch.Load()
+		if latest == highest {
+			return nil
+		}
+		if latest > highest {
+			_, ok, err := c.metadataStore.Load(latest)
+			if err != nil {
+				return fmt.Errorf("failed to load metadata for batch %d: %w", latest, err)
+			}
+			if ok {
+				return nil
+			}
+		}
+		if c.latestBatch.CompareAndSwap(latest, highest) {
+			return nil
+		}
+	}
+}
+
+// GetLatestBatchMetadata returns the metadata with the highest batch number,
+// or ErrNoBatches if nothing has been stored.
+func (c *CDKIntegration) GetLatestBatchMetadata() (*BatchMetadata, error) {
+	for attempt := 0; ; attempt++ {
+		batchNumber := c.latestBatch.Load()
+
+		metadata, ok, err := c.metadataStore.Load(batchNumber)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load metadata for batch %d: %w", batchNumber, err)
+		}
+		if ok {
+			return migrateMetadata(metadata)
+		}
+		if attempt > 0 {
+			return nil, ErrNoBatches
+		}
+
+		// The pointed-to batch was removed; fall back to whatever is left.
+		if err := c.recomputeLatest(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *CDKIntegration) RetrieveBatchData(batchNumber uint64) (data []byte, err error) {
+	defer func() { c.audit(c.ctx, AuditOpRetrieve, batchNumber, err) }()
+
 	metadata, err := c.GetBatchMetadata(batchNumber)
 	if err != nil {
 		return nil, err
 	}
+
+	return c.fetchBatchData(c.ctx, metadata)
+}
+
+// fetchBatchData downloads a batch's data, decompressing it if it was
+// published compressed.
+func (c *CDKIntegration) fetchBatchData(ctx context.Context, metadata *BatchMetadata) ([]byte, error) {
+	data, err := c.fetchPublishedData(ctx, metadata)
+	if err != nil {
+		return nil, err
+	}
+	return decompressBatchData(metadata, data)
+}
+
+// fetchPublishedData downloads a batch's data as it was published.
+func (c *CDKIntegration) fetchPublishedData(ctx context.Context, metadata *BatchMetadata) ([]byte, error) {
+	if metadata.DALayer == DALayerFallback {
+		return c.publisher.RetrieveFallback(ctx, metadata.RefID)
+	}
 	
-	return c.publisher.RetrieveBatch(c.ctx, metadata.CelestiaHeight, metadata.Commitment)
+	namespace, err := c.batchNamespace(metadata)
+	if err != nil {
+		return nil, err
+	}
+	
+	if metadata.RefID == "" {
+		return c.publisher.retrieve(ctx, namespace, metadata.CelestiaHeight, metadata.Commitment, 0)
+	}
+
+	height, commitment, err := parseRefID(metadata.RefID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ref ID for batch %d: %w", metadata.BatchNumber, err)
+	}
+	return c.publisher.retrieve(ctx, namespace, height, commitment, 0)
+}
+
+// GetBatchDataSize returns the size of a batch's data from its metadata. For
+// records written before sizes were recorded, the data is downloaded once and
+// its size saved back to the metadata.
+func (c *CDKIntegration) GetBatchDataSize(ctx context.Context, batchNumber uint64) (uint64, error) {
+	metadata, ok, err := c.metadataStore.Load(batchNumber)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load metadata for batch %d: %w", batchNumber, err)
+	}
+	if !ok {
+		return 0, fmt.Errorf("batch %d: %w", batchNumber, ErrBatchNotFound)
+	}
+	if metadata.Size > 0 {
+		return metadata.Size, nil
+	}
+
+	data, err := c.fetchBatchData(ctx, metadata)
+	if err != nil {
+		return 0, err
+	}
+
+	updated := *metadata
+	updated.Size = uint64(len(data))
+
+	c.snapshotMu.RLock()
+	err = c.metadataStore.Store(&updated)
+	c.snapshotMu.RUnlock()
+	if err != nil {
+		return 0, fmt.Errorf("failed to store metadata for batch %d: %w", batchNumber, err)
+	}
+
+	return updated.Size, nil
 }
 
 func (c *CDKIntegration) ExportMetadata() ([]byte, error) {
 	var allMetadata []*BatchMetadata
 	
-	c.metadataStore.Range(func(key, value interface{}) bool {
-		if metadata, ok := value.(*BatchMetadata); ok {
-			allMetadata = append(allMetadata, metadata)
-		}
+	err := c.metadataStore.Range(func(metadata *BatchMetadata) bool {
+		allMetadata = append(allMetadata, metadata)
 		return true
 	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata store: %w", err)
+	}
 	
 	return json.MarshalIndent(allMetadata, "", "  ")
 }
 
+func (c *CDKIntegration) sortedMetadata() ([]*BatchMetadata, error) {
+	var entries []*BatchMetadata
+	err := c.metadataStore.Range(func(metadata *BatchMetadata) bool {
+		entries = append(entries, metadata)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata store: %w", err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].BatchNumber < entries[j].BatchNumber
+	})
+	return entries, nil
+}
+
+// BatchMetadataBackup writes, in the ExportMetadata JSON format, every batch
+// recorded after sinceTimestamp, in ascending batch number order. Passing the
+// time of the previous backup gives an incremental backup. It returns the
+// number of entries written.
+func (c *CDKIntegration) BatchMetadataBackup(w io.Writer, sinceTimestamp time.Time) (int, error) {
+	entries, err := c.sortedMetadata()
+	if err != nil {
+		return 0, err
+	}
+
+	backup := make([]*BatchMetadata, 0, len(entries))
+	for _, metadata := range entries {
+		if metadata.Timestamp.After(sinceTimestamp) {
+			backup = append(backup, metadata)
+		}
+	}
+
+	data, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return 0, fmt.Errorf("failed to write metadata backup: %w", err)
+	}
+	return len(backup), nil
+}
+
+// ExportMetadataCSV writes all metadata as CSV, one row per batch in
+// ascending batch order, with timestamps in RFC3339 UTC. Fields containing
+// commas or quotes are quoted by encoding/csv.
+func (c *CDKIntegration) ExportMetadataCSV(w io.Writer) error {
+	entries, err := c.sortedMetadata()
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"batch_number", "state_root", "timestamp", "tx_count", "celestia_height", "commitment", "size"})
+
+	for _, metadata := range entries {
+		writer.Write([]string{
+			strconv.FormatUint(metadata.BatchNumber, 10),
+			metadata.StateRoot,
+			metadata.Timestamp.UTC().Format(time.RFC3339),
+			strconv.Itoa(metadata.TxCount),
+			strconv.FormatUint(metadata.CelestiaHeight, 10),
+			metadata.Commitment,
+			strconv.FormatUint(metadata.Size, 10),
+		})
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write metadata CSV: %w", err)
+	}
+	return nil
+}
+
+// TotalBytesPublished sums the data size of every stored batch.
+func (c *CDKIntegration) TotalBytesPublished() uint64 {
+	var total uint64
+	c.metadataStore.Range(func(metadata *BatchMetadata) bool {
+		total += metadata.Size
+		return true
+	})
+	return total
+}
+
+// CacheStats reports metadata cache activity. All counters are zero when
+// Config.MetadataCacheSize is not set.
+func (c *CDKIntegration) CacheStats() (hits, misses, evictions int64) {
+	if c.metadataCache == nil {
+		return 0, 0, 0
+	}
+	return c.metadataCache.hits.Load(), c.metadataCache.misses.Load(), c.metadataCache.evictions.Load()
+}
+
+// Close stops the integration immediately, abandoning queued batches, and
+// waits for workers to give up the batches they hold before closing the
+// publisher, store and audit log. Use StopGracefully to let the queue drain
+// first.
 func (c *CDKIntegration) Close() error {
-	c.cancel()
-	close(c.batchQueue)
-	return c.publisher.Close()
+	if !c.stopAccepting() {
+		return nil
+	}
+	c.stopWorkers()
+
+	c.queueMu.Lock()
+	close(c.batchQueue.Load().batches)
+	c.queueMu.Unlock()
+
+	err := c.closePublisher()
+	if storeErr := c.closeOwnedStore(); err == nil {
+		err = storeErr
+	}
+	if auditErr := c.closeAuditLog(); err == nil {
+		err = auditErr
+	}
+	return err
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+	return copied
 }
\ No newline at end of file
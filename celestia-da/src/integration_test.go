@@ -0,0 +1,837 @@
+package celestiada
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestIntegration builds an integration around fake that is closed when
+// the test ends.
+func newTestIntegration(t *testing.T, config Config, fake *FakePublisher) *CDKIntegration {
+	t.Helper()
+
+	c, err := newCDKIntegration(config, fake)
+	if err != nil {
+		t.Fatalf("newCDKIntegration: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestFlushQueueReturnsAfterLastBatch(t *testing.T) {
+	fake := NewFakePublisher()
+	c := newTestIntegration(t, Config{}, fake)
+
+	c.SuspendProcessing()
+
+	var results []<-chan PublishResult
+	for i := uint64(1); i <= 3; i++ {
+		results = append(results, c.SubmitBatch(i, []byte(fmt.Sprintf("batch %d", i)), "root", 1))
+	}
+
+	flushed := make(chan error, 1)
+	go func() { flushed <- c.FlushQueue(context.Background()) }()
+
+	select {
+	case err := <-flushed:
+		t.Fatalf("FlushQueue returned %v while batches were queued", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	c.ResumeProcessing()
+
+	select {
+	case err := <-flushed:
+		if err != nil {
+			t.Fatalf("FlushQueue: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("FlushQueue did not return after the queue drained")
+	}
+
+	for i, resultChan := range results {
+		select {
+		case result := <-resultChan:
+			if !result.Success {
+				t.Fatalf("batch %d failed: %v", i+1, result.Error)
+			}
+		default:
+			t.Fatalf("FlushQueue returned before batch %d completed", i+1)
+		}
+	}
+	if got := len(fake.PublishedBlobs); got != 3 {
+		t.Fatalf("published %d blobs, want 3", got)
+	}
+}
+
+func TestFlushQueueHonorsContext(t *testing.T) {
+	c := newTestIntegration(t, Config{}, NewFakePublisher())
+
+	c.SuspendProcessing()
+	defer c.ResumeProcessing()
+	c.SubmitBatch(1, []byte("batch"), "root", 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := c.FlushQueue(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("FlushQueue = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestLatestBatchWithOutOfOrderSubmissions(t *testing.T) {
+	c := newTestIntegration(t, Config{}, NewFakePublisher())
+
+	c.SuspendProcessing()
+	var results []<-chan PublishResult
+	for _, i := range []uint64{7, 3, 10, 1, 9, 4, 2, 8, 6, 5} {
+		results = append(results, c.SubmitBatch(i, []byte(fmt.Sprintf("batch %d", i)), "root", 1))
+	}
+	c.ResumeProcessing()
+
+	for _, resultChan := range results {
+		if result := <-resultChan; !result.Success {
+			t.Fatalf("batch failed: %v", result.Error)
+		}
+	}
+
+	latest, err := c.GetLatestBatchMetadata()
+	if err != nil {
+		t.Fatalf("GetLatestBatchMetadata: %v", err)
+	}
+	if latest.BatchNumber != 10 {
+		t.Fatalf("latest batch = %d, want 10", latest.BatchNumber)
+	}
+}
+
+func TestLatestBatchAfterRemoval(t *testing.T) {
+	store := NewMemoryMetadataStore()
+	c := newTestIntegration(t, Config{MetadataStore: store}, NewFakePublisher())
+
+	for i := uint64(1); i <= 3; i++ {
+		if err := c.storeMetadata(&BatchMetadata{BatchNumber: i, Timestamp: time.Now()}); err != nil {
+			t.Fatalf("storeMetadata: %v", err)
+		}
+	}
+	if err := store.Delete(3); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	latest, err := c.GetLatestBatchMetadata()
+	if err != nil {
+		t.Fatalf("GetLatestBatchMetadata: %v", err)
+	}
+	if latest.BatchNumber != 2 {
+		t.Fatalf("latest batch = %d, want 2", latest.BatchNumber)
+	}
+	if latest.SchemaVersion != MetadataSchemaVersion {
+		t.Fatalf("schema version = %d, want %d", latest.SchemaVersion, MetadataSchemaVersion)
+	}
+
+	if err := c.evictMetadata(MaxCountPolicy(0)); err != nil {
+		t.Fatalf("evictMetadata: %v", err)
+	}
+	if _, err := c.GetLatestBatchMetadata(); err != ErrNoBatches {
+		t.Fatalf("GetLatestBatchMetadata = %v, want %v", err, ErrNoBatches)
+	}
+}
+
+func TestWatchBatchFailuresReceivesFailedBatch(t *testing.T) {
+	fake := NewFakePublisher()
+	fake.PublishErr = errors.New("node unavailable")
+	c := newTestIntegration(t, Config{MaxRetries: 2, RetryDelay: time.Millisecond}, fake)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	failures := c.WatchBatchFailures(ctx)
+
+	result := <-c.SubmitBatch(42, []byte("batch"), "root", 1)
+	if result.Success {
+		t.Fatal("batch succeeded with a failing publisher")
+	}
+
+	select {
+	case failure := <-failures:
+		if failure.BatchNumber != 42 {
+			t.Fatalf("failure for batch %d, want 42", failure.BatchNumber)
+		}
+		if !errors.Is(failure.Error, fake.PublishErr) {
+			t.Fatalf("failure error = %v, want %v", failure.Error, fake.PublishErr)
+		}
+		if failure.Attempts != 3 {
+			t.Fatalf("attempts = %d, want 3", failure.Attempts)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no failure delivered to the watcher")
+	}
+}
+
+func TestCloseWaitsForWorkers(t *testing.T) {
+	fake := NewFakePublisher()
+	fake.PublishDelay = 50 * time.Millisecond
+	c, err := newCDKIntegration(Config{}, fake)
+	if err != nil {
+		t.Fatalf("newCDKIntegration: %v", err)
+	}
+
+	resultChan := c.SubmitBatch(1, []byte("batch"), "root", 1)
+	time.Sleep(10 * time.Millisecond)
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	select {
+	case result := <-resultChan:
+		if result.Success {
+			t.Fatal("batch succeeded after Close cancelled it")
+		}
+	default:
+		t.Fatal("Close returned while a worker still held a batch")
+	}
+}
+
+func TestStopGracefullyCountsOnlyUnstartedBatchesAsDropped(t *testing.T) {
+	fake := NewFakePublisher()
+	fake.PublishDelay = 200 * time.Millisecond
+	c, err := newCDKIntegration(Config{WorkerCount: 1}, fake)
+	if err != nil {
+		t.Fatalf("newCDKIntegration: %v", err)
+	}
+
+	for i := uint64(1); i <= 3; i++ {
+		c.SubmitBatch(i, []byte(fmt.Sprintf("batch %d", i)), "root", 1)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	err = c.StopGracefully(20 * time.Millisecond)
+	var timeout ErrShutdownTimeout
+	if !errors.As(err, &timeout) {
+		t.Fatalf("StopGracefully = %v, want ErrShutdownTimeout", err)
+	}
+	if timeout.Report.DroppedBatches != 2 {
+		t.Fatalf("dropped %d batches, want 2", timeout.Report.DroppedBatches)
+	}
+	if timeout.Report.DrainedBatches != 0 || timeout.Report.FailedBatches != 1 {
+		t.Fatalf("drained %d and failed %d batches, want the cancelled batch failed",
+			timeout.Report.DrainedBatches, timeout.Report.FailedBatches)
+	}
+}
+
+func TestStopGracefullyReportsFailedBatchesSeparately(t *testing.T) {
+	fake := NewFakePublisher()
+	fake.PublishDelay = 100 * time.Millisecond
+	c, err := newCDKIntegration(Config{WorkerCount: 1}, fake)
+	if err != nil {
+		t.Fatalf("newCDKIntegration: %v", err)
+	}
+	c.RegisterBatchValidator(MaxTxCountValidator{MaxTxCount: 1})
+
+	c.SubmitBatch(1, []byte("batch 1"), "root", 1)
+	c.SubmitBatch(2, []byte("batch 2"), "root", 5)
+	c.SubmitBatch(3, []byte("batch 3"), "root", 1)
+	time.Sleep(10 * time.Millisecond)
+
+	err = c.StopGracefully(150 * time.Millisecond)
+	var timeout ErrShutdownTimeout
+	if !errors.As(err, &timeout) {
+		t.Fatalf("StopGracefully = %v, want ErrShutdownTimeout", err)
+	}
+	report := timeout.Report
+	if report.DrainedBatches != 1 || report.FailedBatches != 2 || report.DroppedBatches != 0 {
+		t.Fatalf("report = %+v, want 1 drained, 2 failed and 0 dropped", report)
+	}
+}
+
+func TestIntegrityCheckAfterEviction(t *testing.T) {
+	c := newTestIntegration(t, Config{}, NewFakePublisher())
+
+	for i := uint64(1); i <= 5; i++ {
+		metadata := &BatchMetadata{
+			BatchNumber:    i,
+			Timestamp:      time.Now(),
+			CelestiaHeight: i,
+			Commitment:     "abcd",
+			RefID:          fmt.Sprintf("%d:abcd", i),
+		}
+		if i == 1 {
+			// Legacy records have no ref ID.
+			metadata.RefID = ""
+		}
+		if err := c.storeMetadata(metadata); err != nil {
+			t.Fatalf("storeMetadata: %v", err)
+		}
+	}
+	if problems := c.MetadataIntegrityCheck(); len(problems) != 0 {
+		t.Fatalf("integrity check before eviction: %v", problems)
+	}
+
+	if err := c.evictMetadata(MaxCountPolicy(2)); err != nil {
+		t.Fatalf("evictMetadata: %v", err)
+	}
+	if problems := c.MetadataIntegrityCheck(); len(problems) != 0 {
+		t.Fatalf("integrity check after eviction: %v", problems)
+	}
+	if tail := c.TailBatches(10); len(tail) != 2 || tail[0].BatchNumber != 5 || tail[1].BatchNumber != 4 {
+		t.Fatalf("tail after eviction = %v, want batches 5 and 4", tail)
+	}
+}
+
+func TestFeeLimitIsEnforced(t *testing.T) {
+	fake := NewFakePublisher()
+	c := newTestIntegration(t, Config{}, fake)
+	data := []byte("batch")
+	fee := estimateDataGas(data)
+
+	result := <-c.SubmitBatchWithFeeLimit(context.Background(), 1, data, "root", 1, fee-1)
+	if !errors.Is(result.Error, ErrFeeLimitExceeded) {
+		t.Fatalf("batch over its fee limit: error = %v, want %v", result.Error, ErrFeeLimitExceeded)
+	}
+
+	_, err := c.SubmitBatchGroup(context.Background(), []*BatchData{
+		{Number: 2, Data: data, StateRoot: "root", TxCount: 1},
+		{Number: 3, Data: data, StateRoot: "root", TxCount: 1, MaxFeeUTIA: fee - 1},
+	})
+	if !errors.Is(err, ErrFeeLimitExceeded) {
+		t.Fatalf("group with a batch over its fee limit: error = %v, want %v", err, ErrFeeLimitExceeded)
+	}
+	if got := len(fake.PublishedBlobs); got != 0 {
+		t.Fatalf("published %d blobs over the fee limit", got)
+	}
+
+	result = <-c.SubmitBatchWithFeeLimit(context.Background(), 4, data, "root", 1, fee)
+	if !result.Success {
+		t.Fatalf("batch within its fee limit failed: %v", result.Error)
+	}
+}
+
+func TestHighPriorityFeesAreAccounted(t *testing.T) {
+	c := newTestIntegration(t, Config{}, NewFakePublisher())
+	data := []byte("batch")
+	gas := estimateDataGas(data)
+
+	result := <-c.SubmitBatchWithMetadata(context.Background(), &BatchData{
+		Number:          1,
+		Data:            data,
+		StateRoot:       "root",
+		TxCount:         1,
+		UseHighPriority: true,
+		MaxFeeUTIA:      2*gas - 1,
+	}, nil)
+	if !errors.Is(result.Error, ErrFeeLimitExceeded) {
+		t.Fatalf("fee check at the priority price: error = %v, want %v", result.Error, ErrFeeLimitExceeded)
+	}
+
+	result = <-c.SubmitBatchWithMetadata(context.Background(), &BatchData{
+		Number:          2,
+		Data:            data,
+		StateRoot:       "root",
+		TxCount:         1,
+		UseHighPriority: true,
+		MaxFeeUTIA:      2 * gas,
+	}, nil)
+	if !result.Success {
+		t.Fatalf("high-priority batch failed: %v", result.Error)
+	}
+
+	summary, err := c.BatchCostSummary(2)
+	if err != nil {
+		t.Fatalf("BatchCostSummary: %v", err)
+	}
+	if summary.GasPrice != 2 || summary.CostUTIA != float64(2*gas) {
+		t.Fatalf("cost summary = %+v, want gas price 2 and cost %d", summary, 2*gas)
+	}
+	if got := c.Stats().HighPriorityBatches; got != 1 {
+		t.Fatalf("high-priority batches = %d, want 1", got)
+	}
+}
+
+func TestSubmitBatchWithPriority(t *testing.T) {
+	c := newTestIntegration(t, Config{}, NewFakePublisher())
+
+	result := <-c.SubmitBatchWithPriority(context.Background(), 1, []byte("batch"), "root", 1, time.Time{})
+	if !result.Success {
+		t.Fatalf("high-priority batch failed: %v", result.Error)
+	}
+	if result.Metadata.GasPrice != 2 {
+		t.Fatalf("gas price = %v, want the priority price 2", result.Metadata.GasPrice)
+	}
+
+	result = <-c.SubmitBatchWithPriority(context.Background(), 2, []byte("batch"), "root", 1, time.Now().Add(-time.Second))
+	if !errors.Is(result.Error, ErrDeadlineExceeded) {
+		t.Fatalf("expired batch: error = %v, want %v", result.Error, ErrDeadlineExceeded)
+	}
+}
+
+func TestSubmitBatchGroupHonorsPriorityAndDeadline(t *testing.T) {
+	fake := NewFakePublisher()
+	c := newTestIntegration(t, Config{}, fake)
+
+	resultChan, err := c.SubmitBatchGroup(context.Background(), []*BatchData{
+		{Number: 1, Data: []byte("one"), StateRoot: "root", TxCount: 1, UseHighPriority: true},
+		{Number: 2, Data: []byte("two"), StateRoot: "root", TxCount: 1, Deadline: time.Now().Add(-time.Second)},
+		{Number: 3, Data: []byte("three"), StateRoot: "root", TxCount: 1},
+	})
+	if err != nil {
+		t.Fatalf("SubmitBatchGroup: %v", err)
+	}
+	results := <-resultChan
+
+	if !errors.Is(results[1].Error, ErrDeadlineExceeded) {
+		t.Fatalf("expired batch: error = %v, want %v", results[1].Error, ErrDeadlineExceeded)
+	}
+	for _, i := range []int{0, 2} {
+		if !results[i].Success {
+			t.Fatalf("batch %d failed: %v", i+1, results[i].Error)
+		}
+		if results[i].Metadata.GasPrice != 2 {
+			t.Fatalf("batch %d gas price = %v, want the priority price 2", i+1, results[i].Metadata.GasPrice)
+		}
+	}
+	if got := len(fake.PublishedBlobs); got != 2 {
+		t.Fatalf("published %d blobs, want 2", got)
+	}
+}
+
+func TestBatchMetadataWatchFromDeliversEveryBatch(t *testing.T) {
+	c := newTestIntegration(t, Config{}, NewFakePublisher())
+
+	// A legacy record in the history is migrated before delivery.
+	if err := c.storeMetadata(&BatchMetadata{BatchNumber: 1, Timestamp: time.Now()}); err != nil {
+		t.Fatalf("storeMetadata: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates := c.BatchMetadataWatchFrom(ctx, 1)
+
+	// Far more live entries than the watcher buffer, stored before the
+	// consumer reads anything.
+	const live = 4 * metadataWatcherBuffer
+	for i := uint64(2); i <= live+1; i++ {
+		metadata := &BatchMetadata{BatchNumber: i, Timestamp: time.Now(), SchemaVersion: MetadataSchemaVersion}
+		if err := c.storeMetadata(metadata); err != nil {
+			t.Fatalf("storeMetadata: %v", err)
+		}
+		c.notifyMetadata(metadata)
+	}
+
+	for want := uint64(1); want <= live+1; want++ {
+		select {
+		case metadata := <-updates:
+			if metadata.BatchNumber != want {
+				t.Fatalf("got batch %d, want %d", metadata.BatchNumber, want)
+			}
+			if metadata.SchemaVersion != MetadataSchemaVersion {
+				t.Fatalf("batch %d has schema version %d", want, metadata.SchemaVersion)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("batch %d was never delivered", want)
+		}
+	}
+}
+
+func TestBatchMetadataWatchFromClosesWhenConsumerFallsBehind(t *testing.T) {
+	c := newTestIntegration(t, Config{}, NewFakePublisher())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates := c.BatchMetadataWatchFrom(ctx, 1)
+
+	const live = 2 * metadataWatchQueueSize
+	for i := uint64(1); i <= live; i++ {
+		metadata := &BatchMetadata{BatchNumber: i, Timestamp: time.Now(), SchemaVersion: MetadataSchemaVersion}
+		if err := c.storeMetadata(metadata); err != nil {
+			t.Fatalf("storeMetadata: %v", err)
+		}
+		c.notifyMetadata(metadata)
+	}
+
+	// Everything delivered before the watch closes is in order and without
+	// gaps, so the consumer can resume after the last batch it received.
+	var received uint64
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case metadata, ok := <-updates:
+			if !ok {
+				if received >= live {
+					t.Fatalf("watch delivered all %d batches, want it closed early", received)
+				}
+				return
+			}
+			received++
+			if metadata.BatchNumber != received {
+				t.Fatalf("got batch %d, want %d", metadata.BatchNumber, received)
+			}
+		case <-timeout:
+			t.Fatalf("watch was not closed after %d batches", received)
+		}
+	}
+}
+
+type batchListenerFunc func(*BatchMetadata)
+
+func (f batchListenerFunc) OnBatch(m *BatchMetadata) { f(m) }
+
+func TestBatchListenersFollowStrictOrderingWithoutStallingWorkers(t *testing.T) {
+	c := newTestIntegration(t, Config{WorkerCount: 4, StrictOrdering: true}, NewFakePublisher())
+
+	release := make(chan struct{})
+	seen := make(chan uint64, 16)
+	err := c.AddBatchListener("slow", batchListenerFunc(func(m *BatchMetadata) {
+		<-release
+		seen <- m.BatchNumber
+	}))
+	if err != nil {
+		t.Fatalf("AddBatchListener: %v", err)
+	}
+
+	const batches = 8
+	var results []<-chan PublishResult
+	for i := uint64(1); i <= batches; i++ {
+		results = append(results, c.SubmitBatch(i, []byte(fmt.Sprintf("batch %d", i)), "root", 1))
+	}
+
+	// The listener has not returned once, yet every batch completes.
+	for i, resultChan := range results {
+		select {
+		case result := <-resultChan:
+			if !result.Success {
+				t.Fatalf("batch %d failed: %v", i+1, result.Error)
+			}
+		case <-time.After(5 * time.Second):
+			close(release)
+			t.Fatalf("batch %d stalled behind a blocked listener", i+1)
+		}
+	}
+	close(release)
+
+	for want := uint64(1); want <= batches; want++ {
+		select {
+		case got := <-seen:
+			if got != want {
+				t.Fatalf("listener got batch %d, want %d", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("listener never got batch %d", want)
+		}
+	}
+}
+
+func TestMetadataChangesAreAudited(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	c := newTestIntegration(t, Config{AuditLogPath: path}, NewFakePublisher())
+	ctx := context.Background()
+
+	group, err := c.SubmitBatchGroup(ctx, []*BatchData{
+		{Number: 1, Data: []byte("batch 1")},
+		{Number: 2, Data: []byte("batch 2")},
+	})
+	if err != nil {
+		t.Fatalf("SubmitBatchGroup: %v", err)
+	}
+	<-group
+
+	external := &BatchMetadata{BatchNumber: 3, CelestiaHeight: 7, Commitment: "abcd"}
+	if result := <-c.SubmitBatchWithMetadata(ctx, &BatchData{Number: 3, Data: []byte("batch 3")}, external); !result.Success {
+		t.Fatalf("SubmitBatchWithMetadata: %v", result.Error)
+	}
+
+	imported, err := json.Marshal([]*BatchMetadata{{BatchNumber: 4, Timestamp: time.Now(), CelestiaHeight: 8, Commitment: "abcd"}})
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	if err := c.ImportMetadata(imported); err != nil {
+		t.Fatalf("ImportMetadata: %v", err)
+	}
+
+	if err := c.evictMetadata(MaxCountPolicy(3)); err != nil {
+		t.Fatalf("evictMetadata: %v", err)
+	}
+	if err := c.DeleteBatchMetadata(ctx, 4); err != nil {
+		t.Fatalf("DeleteBatchMetadata: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry AuditEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Unmarshal %q: %v", line, err)
+		}
+		got = append(got, fmt.Sprintf("%s %d %s", entry.Operation, entry.BatchNumber, entry.Result))
+	}
+	want := []string{"submit 1 ok", "submit 2 ok", "submit 3 ok", "import 4 ok", "evict 1 ok", "delete 4 ok"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Fatalf("audit log = %v, want %v", got, want)
+	}
+}
+
+func TestAuditLogRecoversFromPartialLastLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	log, err := openAuditLog(path)
+	if err != nil {
+		t.Fatalf("openAuditLog: %v", err)
+	}
+	if err := log.record(AuditEntry{Operation: AuditOpSubmit, BatchNumber: 1, Result: "ok"}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	log.close()
+
+	// A blank line, then an entry cut short by a crash.
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	file.WriteString("\n{\"timestamp\":\"2026-")
+	file.Close()
+
+	log, err = openAuditLog(path)
+	if err != nil {
+		t.Fatalf("openAuditLog: %v", err)
+	}
+	if err := log.record(AuditEntry{Operation: AuditOpSubmit, BatchNumber: 2, Result: "ok"}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	log.close()
+
+	valid, line, err := VerifyAuditLog(path)
+	if err != nil {
+		t.Fatalf("VerifyAuditLog: %v", err)
+	}
+	if !valid {
+		t.Fatalf("audit log broken at line %d after recovering from a partial line", line)
+	}
+}
+
+func TestCompactionKeepsProcessingSuspended(t *testing.T) {
+	store, err := NewFileMetadataStore(filepath.Join(t.TempDir(), "metadata.jsonl"))
+	if err != nil {
+		t.Fatalf("NewFileMetadataStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	c := newTestIntegration(t, Config{MetadataStore: store}, NewFakePublisher())
+
+	c.SuspendProcessing()
+	resultChan := c.SubmitBatch(1, []byte("batch"), "root", 1)
+	if err := c.CompactMetadataStore(context.Background()); err != nil {
+		t.Fatalf("CompactMetadataStore: %v", err)
+	}
+
+	select {
+	case result := <-resultChan:
+		t.Fatalf("batch processed while suspended: %+v", result)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	c.ResumeProcessing()
+	select {
+	case result := <-resultChan:
+		if !result.Success {
+			t.Fatalf("batch failed: %v", result.Error)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("batch not processed after ResumeProcessing")
+	}
+}
+
+func TestSubmitBatchGroupRetriesAndRecordsResults(t *testing.T) {
+	fake := NewFakePublisher()
+	fake.PublishErr = errors.New("node unavailable")
+	var onError []uint64
+	config := Config{
+		MaxRetries: 2,
+		RetryDelay: time.Millisecond,
+		OnError: func(batchNumber uint64, err error, retry int) {
+			onError = append(onError, batchNumber)
+			fake.mu.Lock()
+			fake.PublishErr = nil
+			fake.mu.Unlock()
+		},
+	}
+	c := newTestIntegration(t, config, fake)
+
+	group, err := c.SubmitBatchGroup(context.Background(), []*BatchData{
+		{Number: 1, Data: []byte("batch 1")},
+		{Number: 2, Data: []byte("batch 2")},
+	})
+	if err != nil {
+		t.Fatalf("SubmitBatchGroup: %v", err)
+	}
+	for _, result := range <-group {
+		if !result.Success {
+			t.Fatalf("batch failed: %v", result.Error)
+		}
+	}
+	if len(onError) != 2 {
+		t.Fatalf("OnError called for %v, want both batches once", onError)
+	}
+	if stats := c.Stats(); stats.BatchesPublished != 2 || stats.BatchesFailed != 0 {
+		t.Fatalf("stats = %+v, want 2 published and 0 failed", stats)
+	}
+}
+
+func TestSubmitBatchGroupReportsFailures(t *testing.T) {
+	fake := NewFakePublisher()
+	fake.PublishErr = errors.New("node unavailable")
+	c := newTestIntegration(t, Config{MaxRetries: 1, RetryDelay: time.Millisecond}, fake)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	failures := c.WatchBatchFailures(ctx)
+
+	group, err := c.SubmitBatchGroup(context.Background(), []*BatchData{
+		{Number: 1, Data: []byte("batch 1")},
+		{Number: 2, Data: []byte("batch 2")},
+	})
+	if err != nil {
+		t.Fatalf("SubmitBatchGroup: %v", err)
+	}
+	for _, result := range <-group {
+		if result.Success {
+			t.Fatal("batch succeeded with a failing publisher")
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case failure := <-failures:
+			if failure.Attempts != 2 {
+				t.Fatalf("attempts = %d, want 2", failure.Attempts)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no failure delivered to the watcher")
+		}
+	}
+	if stats := c.Stats(); stats.BatchesFailed != 2 {
+		t.Fatalf("failed batches = %d, want 2", stats.BatchesFailed)
+	}
+
+	fake.mu.Lock()
+	fake.PublishErr = nil
+	fake.mu.Unlock()
+	if count, errs := c.ResubmitFailed(context.Background()); count != 2 || len(errs) != 0 {
+		t.Fatalf("ResubmitFailed = %d, %v, want both batches resubmitted", count, errs)
+	}
+}
+
+func TestCompressedBatchesReadBack(t *testing.T) {
+	fake := NewFakePublisher()
+	config := Config{Compression: CompressionGzip}
+	c := newTestIntegration(t, config, fake)
+	data := bytes.Repeat([]byte("compressible batch data "), 100)
+
+	result := <-c.SubmitBatch(1, data, "root", 1)
+	if !result.Success {
+		t.Fatalf("SubmitBatch: %v", result.Error)
+	}
+	if published := fake.PublishedBlobs[0]; bytes.Equal(published, data) {
+		t.Fatal("batch was published uncompressed")
+	}
+	metadata := result.Metadata
+	if metadata.Compression != CompressionGzip || metadata.Size != uint64(len(data)) || metadata.CompressedSize >= metadata.Size {
+		t.Fatalf("metadata = %+v, want gzip with a compressed size below %d", metadata, len(data))
+	}
+
+	retrieved, err := c.RetrieveBatchData(1)
+	if err != nil {
+		t.Fatalf("RetrieveBatchData: %v", err)
+	}
+	if !bytes.Equal(retrieved, data) {
+		t.Fatal("retrieved data does not match the submitted batch")
+	}
+
+	replayed := newTestIntegration(t, config, fake)
+	replayed.RegisterBatchDeserializer(func(blob []byte) (*BatchData, error) {
+		if !bytes.Equal(blob, data) {
+			return nil, fmt.Errorf("not batch data")
+		}
+		return &BatchData{Number: 1, Data: blob, StateRoot: "root", TxCount: 1}, nil
+	})
+	if count, err := replayed.ReplayFromCelestia(context.Background(), 1, 10); err != nil || count != 1 {
+		t.Fatalf("ReplayFromCelestia = %d, %v, want 1 batch", count, err)
+	}
+	restored, err := replayed.GetBatchMetadata(1)
+	if err != nil {
+		t.Fatalf("GetBatchMetadata: %v", err)
+	}
+	if restored.Compression != CompressionGzip || restored.Size != uint64(len(data)) {
+		t.Fatalf("replayed metadata = %+v, want gzip and size %d", restored, len(data))
+	}
+}
+
+func TestRegisteredMigrationsRaiseTheSchemaVersion(t *testing.T) {
+	RegisterMigration(MetadataSchemaVersion, MetadataSchemaVersion+1, func(m *BatchMetadata) error {
+		m.DALayer = "migrated"
+		return nil
+	})
+	t.Cleanup(func() {
+		migrationsMu.Lock()
+		delete(migrations, MetadataSchemaVersion)
+		migrationsMu.Unlock()
+	})
+
+	if got := CurrentSchemaVersion(); got != MetadataSchemaVersion+1 {
+		t.Fatalf("CurrentSchemaVersion = %d, want %d", got, MetadataSchemaVersion+1)
+	}
+
+	c := newTestIntegration(t, Config{}, NewFakePublisher())
+	if err := c.storeMetadata(&BatchMetadata{BatchNumber: 1, Timestamp: time.Now(), SchemaVersion: MetadataSchemaVersion}); err != nil {
+		t.Fatalf("storeMetadata: %v", err)
+	}
+	metadata, err := c.GetBatchMetadata(1)
+	if err != nil {
+		t.Fatalf("GetBatchMetadata: %v", err)
+	}
+	if metadata.SchemaVersion != MetadataSchemaVersion+1 || metadata.DALayer != "migrated" {
+		t.Fatalf("metadata = %+v, want it migrated to version %d", metadata, MetadataSchemaVersion+1)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("registering a second migration from the same version did not panic")
+		}
+	}()
+	RegisterMigration(MetadataSchemaVersion, MetadataSchemaVersion+2, func(*BatchMetadata) error { return nil })
+}
+
+func TestSetNamespaceKeepsProcessingSuspended(t *testing.T) {
+	c := newTestIntegration(t, Config{}, NewFakePublisher())
+
+	c.SuspendProcessing()
+	resultChan := c.SubmitBatch(1, []byte("batch"), "root", 1)
+	if err := c.SetNamespace(context.Background(), "00010203040506070809"); err != nil {
+		t.Fatalf("SetNamespace: %v", err)
+	}
+
+	select {
+	case result := <-resultChan:
+		t.Fatalf("batch processed while suspended: %+v", result)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	c.ResumeProcessing()
+	select {
+	case result := <-resultChan:
+		if !result.Success {
+			t.Fatalf("batch failed: %v", result.Error)
+		}
+		if result.Metadata.Namespace != c.publisher.Namespace() {
+			t.Fatalf("batch published under %s, want %s", result.Metadata.Namespace, c.publisher.Namespace())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("batch not processed after ResumeProcessing")
+	}
+}
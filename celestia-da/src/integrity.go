@@ -0,0 +1,102 @@
+package celestiada
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// IntegrityError describes one inconsistency found by MetadataIntegrityCheck.
+// Check names the invariant that failed.
+type IntegrityError struct {
+	BatchNumber uint64
+	Check       string
+	Detail      string
+}
+
+func (e IntegrityError) Error() string {
+	return fmt.Sprintf("batch %d: %s: %s", e.BatchNumber, e.Check, e.Detail)
+}
+
+// MetadataIntegrityCheck scans the metadata store and the integration's
+// in-memory indexes for inconsistencies and returns every one it finds. It
+// checks that each stored entry satisfies the BatchMetadata invariants, and
+// that the LRU cache, the latest batch pointer and the tail buffer only refer
+// to batches present in the backing store. The store is read while batches
+// are being published, so entries written during the scan may be reported
+// as missing; run it on an idle integration for an exact result.
+func (c *CDKIntegration) MetadataIntegrityCheck() []IntegrityError {
+	var problems []IntegrityError
+	report := func(batchNumber uint64, check, format string, args ...interface{}) {
+		problems = append(problems, IntegrityError{
+			BatchNumber: batchNumber,
+			Check:       check,
+			Detail:      fmt.Sprintf(format, args...),
+		})
+	}
+
+	primary := make(map[uint64]bool)
+	err := c.persistentStore().Range(func(metadata *BatchMetadata) bool {
+		primary[metadata.BatchNumber] = true
+		checkMetadataInvariants(metadata, report)
+		return true
+	})
+	if err != nil {
+		report(0, "store", "failed to read metadata store: %v", err)
+		return problems
+	}
+
+	if cache := c.metadataCache; cache != nil {
+		cache.mu.Lock()
+		for batchNumber, elem := range cache.entries {
+			cached := elem.Value.(*BatchMetadata)
+			if cached.BatchNumber != batchNumber {
+				report(batchNumber, "cache", "cache entry holds metadata for batch %d", cached.BatchNumber)
+			}
+			if !primary[batchNumber] {
+				report(batchNumber, "cache", "cached entry has no primary entry")
+			}
+		}
+		cache.mu.Unlock()
+	}
+
+	if latest := c.latestBatch.Load(); latest > 0 && !primary[latest] {
+		report(latest, "latest", "latest batch pointer has no primary entry")
+	}
+
+	c.tailMu.RLock()
+	for _, metadata := range c.recentBatches {
+		if !primary[metadata.BatchNumber] {
+			report(metadata.BatchNumber, "tail", "tail buffer entry has no primary entry")
+		}
+	}
+	c.tailMu.RUnlock()
+
+	return problems
+}
+
+func checkMetadataInvariants(metadata *BatchMetadata, report func(uint64, string, string, ...interface{})) {
+	n := metadata.BatchNumber
+
+	if metadata.Timestamp.IsZero() {
+		report(n, "timestamp", "timestamp is zero")
+	}
+
+	// Batches published to the fallback DA layer have no Celestia location.
+	if metadata.DALayer == DALayerFallback {
+		return
+	}
+
+	if metadata.CelestiaHeight == 0 {
+		report(n, "celestiaHeight", "celestia height is zero")
+	}
+	if metadata.Commitment == "" {
+		report(n, "commitment", "commitment is empty")
+	} else if _, err := hex.DecodeString(metadata.Commitment); err != nil {
+		report(n, "commitment", "commitment %q is not valid hex: %v", metadata.Commitment, err)
+	}
+	// Records written before ref IDs were stored have none; that is not
+	// corruption.
+	if want := fmt.Sprintf("%d:%s", metadata.CelestiaHeight, metadata.Commitment); metadata.RefID != "" && metadata.RefID != want {
+		report(n, "refId", "ref ID %q does not match height and commitment %q", metadata.RefID, want)
+	}
+}
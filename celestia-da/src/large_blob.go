@@ -0,0 +1,288 @@
+package celestiada
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+const (
+	largeBlobHeaderSize = 4 + 4 + sha256.Size
+
+	// maxLargeBlobIndexEntries bounds the shard locations remembered for
+	// RetrieveLargeBlob; older entries must be found with
+	// RetrieveLargeBlobInRange.
+	maxLargeBlobIndexEntries = 256
+
+	// maxLargeBlobShards bounds the shard count RetrieveLargeBlobInRange
+	// accepts from a shard header, which anyone posting to the namespace can
+	// forge.
+	maxLargeBlobShards = 1 << 16
+)
+
+// LargeBlob is data split into ordered shards small enough to fit in a block.
+// Each shard starts with a header carrying its index, the shard count and the
+// SHA-256 parent commitment of the full data.
+type LargeBlob struct {
+	Namespace        share.Namespace
+	ParentCommitment []byte
+	Shards           [][]byte
+}
+
+// largeBlobIndex records where the shards of a published large blob live.
+type largeBlobIndex struct {
+	namespace share.Namespace
+	refIDs    []string
+}
+
+// largeBlobCache holds the largeBlobIndex of the most recently published
+// large blobs, evicting the oldest beyond maxLargeBlobIndexEntries.
+type largeBlobCache struct {
+	mu      sync.Mutex
+	entries map[string]largeBlobIndex
+	order   []string
+}
+
+func (c *largeBlobCache) store(parent string, index largeBlobIndex) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]largeBlobIndex)
+	}
+	if _, ok := c.entries[parent]; !ok {
+		c.order = append(c.order, parent)
+	}
+	c.entries[parent] = index
+
+	for len(c.order) > maxLargeBlobIndexEntries {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+func (c *largeBlobCache) load(parent string) (largeBlobIndex, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	index, ok := c.entries[parent]
+	return index, ok
+}
+
+type largeBlobHeader struct {
+	index  uint32
+	total  uint32
+	parent []byte
+}
+
+// NewLargeBlob shards data so that every encoded shard, header included, is
+// at most maxShardSize bytes.
+func NewLargeBlob(namespace share.Namespace, data []byte, maxShardSize uint64) (*LargeBlob, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("large blob data is empty")
+	}
+	if maxShardSize <= largeBlobHeaderSize {
+		return nil, fmt.Errorf("max shard size %d must exceed shard header size %d", maxShardSize, largeBlobHeaderSize)
+	}
+
+	parent := sha256.Sum256(data)
+	payloadSize := int(maxShardSize - largeBlobHeaderSize)
+	total := (len(data) + payloadSize - 1) / payloadSize
+
+	shards := make([][]byte, 0, total)
+	for i := 0; i < total; i++ {
+		end := (i + 1) * payloadSize
+		if end > len(data) {
+			end = len(data)
+		}
+		payload := data[i*payloadSize : end]
+
+		shard := make([]byte, largeBlobHeaderSize, largeBlobHeaderSize+len(payload))
+		binary.BigEndian.PutUint32(shard[0:4], uint32(i))
+		binary.BigEndian.PutUint32(shard[4:8], uint32(total))
+		copy(shard[8:], parent[:])
+		shards = append(shards, append(shard, payload...))
+	}
+
+	return &LargeBlob{
+		Namespace:        namespace,
+		ParentCommitment: parent[:],
+		Shards:           shards,
+	}, nil
+}
+
+func decodeLargeBlobShard(shard []byte) (largeBlobHeader, []byte, error) {
+	if len(shard) < largeBlobHeaderSize {
+		return largeBlobHeader{}, nil, fmt.Errorf("shard too short: %d bytes", len(shard))
+	}
+
+	header := largeBlobHeader{
+		index:  binary.BigEndian.Uint32(shard[0:4]),
+		total:  binary.BigEndian.Uint32(shard[4:8]),
+		parent: shard[8:largeBlobHeaderSize],
+	}
+	return header, shard[largeBlobHeaderSize:], nil
+}
+
+// PublishLargeBlob submits every shard of lb in order and returns the hex
+// parent commitment used to retrieve it.
+func (p *Publisher) PublishLargeBlob(ctx context.Context, lb *LargeBlob) (string, error) {
+	namespace := p.currentNamespace()
+	if !bytes.Equal(lb.Namespace, namespace) {
+		return "", fmt.Errorf("large blob namespace %x does not match publisher namespace %x", []byte(lb.Namespace), []byte(namespace))
+	}
+
+	refIDs := make([]string, 0, len(lb.Shards))
+	for i, shard := range lb.Shards {
+		refID, err := p.publish(ctx, namespace, shard, 0, p.config.GasPrice)
+		if err != nil {
+			return "", fmt.Errorf("failed to publish shard %d/%d: %w", i+1, len(lb.Shards), err)
+		}
+		refIDs = append(refIDs, refID)
+	}
+
+	parent := hex.EncodeToString(lb.ParentCommitment)
+	p.largeBlobs.store(parent, largeBlobIndex{namespace: namespace, refIDs: refIDs})
+
+	return parent, nil
+}
+
+// RetrieveLargeBlob fetches and reassembles a large blob, validating every
+// shard header and the parent commitment of the result. Shard locations are
+// only remembered in memory for the most recent large blobs this publisher
+// has published; for any other blob, including every blob after a restart,
+// it fails with ErrLargeBlobNotIndexed and RetrieveLargeBlobInRange must be
+// used instead.
+func (p *Publisher) RetrieveLargeBlob(ctx context.Context, parentCommitment string) ([]byte, error) {
+	parent, err := hex.DecodeString(parentCommitment)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parent commitment: %w", err)
+	}
+
+	index, ok := p.largeBlobs.load(parentCommitment)
+	if !ok {
+		return nil, fmt.Errorf("large blob %s: %w", parentCommitment, ErrLargeBlobNotIndexed)
+	}
+
+	shards := make([][]byte, len(index.refIDs))
+	for i, refID := range index.refIDs {
+		height, commitment, err := parseRefID(refID)
+		if err != nil {
+			return nil, err
+		}
+
+		shards[i], err = p.retrieve(ctx, index.namespace, height, commitment, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve shard %d/%d: %w", i+1, len(index.refIDs), err)
+		}
+	}
+
+	return assembleLargeBlob(shards, parent)
+}
+
+// RetrieveLargeBlobInRange finds the shards of a large blob by scanning the
+// current namespace over the inclusive height range and reassembles them. It
+// needs no state from the publishing process, so the range only has to cover
+// the heights the shards were included at, for example the network heads
+// recorded just before and just after PublishLargeBlob. The scan stops as
+// soon as every shard has been found. Anyone can post to the namespace, so
+// shards are grouped by the shard count in their header and a group that
+// does not reassemble to the parent commitment is discarded rather than
+// failing the retrieval.
+func (p *Publisher) RetrieveLargeBlobInRange(ctx context.Context, parentCommitment string, fromHeight, toHeight uint64) ([]byte, error) {
+	parent, err := hex.DecodeString(parentCommitment)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parent commitment: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results, err := p.GetNamespaceBlobs(ctx, fromHeight, toHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	// groups maps a shard count to the shards found by index. Shards are
+	// only collected into a slice once a group is complete, so memory is
+	// bounded by the shards actually found, not by the headers' claims.
+	groups := make(map[uint32]map[uint32][]byte)
+	for result := range results {
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		header, _, err := decodeLargeBlobShard(result.Data)
+		if err != nil || !bytes.Equal(header.parent, parent) {
+			continue
+		}
+		if header.total == 0 || header.total > maxLargeBlobShards || header.index >= header.total {
+			continue
+		}
+
+		group := groups[header.total]
+		if group == nil {
+			group = make(map[uint32][]byte)
+			groups[header.total] = group
+		}
+		if _, ok := group[header.index]; ok {
+			continue
+		}
+		group[header.index] = result.Data
+		if len(group) < int(header.total) {
+			continue
+		}
+
+		shards := make([][]byte, header.total)
+		for index, shard := range group {
+			shards[index] = shard
+		}
+		if data, err := assembleLargeBlob(shards, parent); err == nil {
+			return data, nil
+		}
+		delete(groups, header.total)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("no shards of large blob %s at heights %d-%d", parentCommitment, fromHeight, toHeight)
+	}
+	found, total := 0, uint32(0)
+	for count, group := range groups {
+		if len(group) > found {
+			found, total = len(group), count
+		}
+	}
+	return nil, fmt.Errorf("found %d of %d shards of large blob %s at heights %d-%d", found, total, parentCommitment, fromHeight, toHeight)
+}
+
+// assembleLargeBlob validates shards, given in index order, against parent
+// and returns the reassembled data.
+func assembleLargeBlob(shards [][]byte, parent []byte) ([]byte, error) {
+	var data []byte
+	for i, shard := range shards {
+		header, payload, err := decodeLargeBlobShard(shard)
+		if err != nil {
+			return nil, fmt.Errorf("invalid shard %d/%d: %w", i+1, len(shards), err)
+		}
+		if header.index != uint32(i) || header.total != uint32(len(shards)) || !bytes.Equal(header.parent, parent) {
+			return nil, fmt.Errorf("shard %d/%d header mismatch: index %d, total %d", i+1, len(shards), header.index, header.total)
+		}
+
+		data = append(data, payload...)
+	}
+
+	if sum := sha256.Sum256(data); !bytes.Equal(sum[:], parent) {
+		return nil, fmt.Errorf("reassembled data does not match parent commitment %x", parent)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,91 @@
+package celestiada
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// LoadOrCreate opens the file-backed metadata store at storePath, creating an
+// empty one if the file does not exist, and builds an integration on it. An
+// existing store is replayed, so the integration resumes from the last batch
+// it recorded. A file written by ExportMetadata is imported instead and
+// replaced, atomically, by a store holding the same metadata. Every metadata
+// write is appended and synced to the file, so a crash loses at most the
+// write in progress. The store replaces config.MetadataStore and is closed
+// along with the integration.
+func LoadOrCreate(config Config, storePath string) (*CDKIntegration, error) {
+	if err := convertExportedMetadata(storePath); err != nil {
+		return nil, err
+	}
+
+	store, err := NewFileMetadataStore(storePath)
+	if err != nil {
+		return nil, err
+	}
+
+	config.MetadataStore = store
+	integration, err := NewCDKIntegration(config)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	integration.ownedStore = store
+
+	return integration, nil
+}
+
+// convertExportedMetadata rewrites the file at path as a FileMetadataStore log
+// if it holds a JSON array written by ExportMetadata. The log is built next
+// to it and renamed over it, so a crash leaves the export in place.
+func convertExportedMetadata(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read metadata file: %w", err)
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return nil
+	}
+
+	entries, err := decodeExportedMetadata(data)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".import"
+	if err := os.Remove(tmpPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale metadata import: %w", err)
+	}
+	store, err := NewFileMetadataStore(tmpPath)
+	if err != nil {
+		return err
+	}
+	for _, metadata := range entries {
+		if err := store.Store(metadata); err != nil {
+			store.Close()
+			return fmt.Errorf("failed to store metadata for batch %d: %w", metadata.BatchNumber, err)
+		}
+	}
+	if err := store.Close(); err != nil {
+		return fmt.Errorf("failed to close metadata import: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace exported metadata: %w", err)
+	}
+	return nil
+}
+
+// closeOwnedStore closes the metadata store if the integration opened it.
+func (c *CDKIntegration) closeOwnedStore() error {
+	if c.ownedStore == nil {
+		return nil
+	}
+	if err := c.ownedStore.Close(); err != nil {
+		return fmt.Errorf("failed to close metadata store: %w", err)
+	}
+	return nil
+}
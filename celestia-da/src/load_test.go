@@ -0,0 +1,113 @@
+package celestiada
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadOrCreateRoundTrip(t *testing.T) {
+	config := Config{
+		Endpoint:    "http://127.0.0.1:26658",
+		NamespaceID: "00010203040506070809",
+	}
+	storePath := filepath.Join(t.TempDir(), "metadata.log")
+
+	c, err := LoadOrCreate(config, storePath)
+	if err != nil {
+		t.Fatalf("LoadOrCreate: %v", err)
+	}
+	for i := uint64(1); i <= 10; i++ {
+		err := c.storeMetadata(&BatchMetadata{
+			BatchNumber:    i,
+			StateRoot:      fmt.Sprintf("root %d", i),
+			Timestamp:      time.Unix(int64(i), 0).UTC(),
+			CelestiaHeight: 100 + i,
+			RefID:          fmt.Sprintf("%d:%02x", 100+i, i),
+			SchemaVersion:  MetadataSchemaVersion,
+		})
+		if err != nil {
+			t.Fatalf("storeMetadata: %v", err)
+		}
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	c, err = LoadOrCreate(config, storePath)
+	if err != nil {
+		t.Fatalf("LoadOrCreate after close: %v", err)
+	}
+	defer c.Close()
+
+	for i := uint64(1); i <= 10; i++ {
+		metadata, err := c.GetBatchMetadata(i)
+		if err != nil {
+			t.Fatalf("GetBatchMetadata(%d): %v", i, err)
+		}
+		if metadata.StateRoot != fmt.Sprintf("root %d", i) || metadata.CelestiaHeight != 100+i {
+			t.Fatalf("batch %d reloaded as %+v", i, metadata)
+		}
+	}
+
+	latest, err := c.GetLatestBatchMetadata()
+	if err != nil {
+		t.Fatalf("GetLatestBatchMetadata: %v", err)
+	}
+	if latest.BatchNumber != 10 {
+		t.Fatalf("latest batch = %d, want 10", latest.BatchNumber)
+	}
+}
+
+func TestLoadOrCreateImportsExportedMetadata(t *testing.T) {
+	source := newTestIntegration(t, Config{}, NewFakePublisher())
+	for i := uint64(1); i <= 3; i++ {
+		err := source.storeMetadata(&BatchMetadata{
+			BatchNumber:    i,
+			StateRoot:      fmt.Sprintf("root %d", i),
+			Timestamp:      time.Unix(int64(i), 0).UTC(),
+			CelestiaHeight: 100 + i,
+			RefID:          fmt.Sprintf("%d:%02x", 100+i, i),
+			SchemaVersion:  MetadataSchemaVersion,
+		})
+		if err != nil {
+			t.Fatalf("storeMetadata: %v", err)
+		}
+	}
+	exported, err := source.ExportMetadata()
+	if err != nil {
+		t.Fatalf("ExportMetadata: %v", err)
+	}
+	storePath := filepath.Join(t.TempDir(), "metadata.json")
+	if err := os.WriteFile(storePath, exported, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	config := Config{
+		Endpoint:    "http://127.0.0.1:26658",
+		NamespaceID: "00010203040506070809",
+	}
+	for _, stage := range []string{"import", "reload"} {
+		c, err := LoadOrCreate(config, storePath)
+		if err != nil {
+			t.Fatalf("LoadOrCreate (%s): %v", stage, err)
+		}
+		for i := uint64(1); i <= 3; i++ {
+			metadata, err := c.GetBatchMetadata(i)
+			if err != nil {
+				t.Fatalf("GetBatchMetadata(%d) after %s: %v", i, stage, err)
+			}
+			if metadata.StateRoot != fmt.Sprintf("root %d", i) {
+				t.Fatalf("batch %d after %s = %+v", i, stage, metadata)
+			}
+		}
+		if latest, err := c.GetLatestBatchMetadata(); err != nil || latest.BatchNumber != 3 {
+			t.Fatalf("latest batch after %s = %v, %v, want 3", stage, latest, err)
+		}
+		if err := c.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	}
+}
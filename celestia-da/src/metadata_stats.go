@@ -0,0 +1,98 @@
+package celestiada
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const metadataStatsTTL = 5 * time.Second
+
+type BatchMetadataStats struct {
+	TotalBatches           int
+	TotalTxCount           int64
+	TotalBytes             uint64
+	OldestBatch            *BatchMetadata
+	NewestBatch            *BatchMetadata
+	AverageTxCountPerBatch float64
+	AverageBatchSizeBytes  float64
+}
+
+// BatchMetadataStats aggregates every stored batch. Oldest and newest are by
+// batch number. The store is scanned at most once per metadataStatsTTL.
+func (c *CDKIntegration) BatchMetadataStats() BatchMetadataStats {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+
+	if !c.statsAt.IsZero() && time.Since(c.statsAt) < metadataStatsTTL {
+		return c.statsCache
+	}
+
+	var stats BatchMetadataStats
+	c.metadataStore.Range(func(metadata *BatchMetadata) bool {
+		stats.TotalBatches++
+		stats.TotalTxCount += int64(metadata.TxCount)
+		stats.TotalBytes += metadata.Size
+		if stats.OldestBatch == nil || metadata.BatchNumber < stats.OldestBatch.BatchNumber {
+			stats.OldestBatch = metadata
+		}
+		if stats.NewestBatch == nil || metadata.BatchNumber > stats.NewestBatch.BatchNumber {
+			stats.NewestBatch = metadata
+		}
+		return true
+	})
+
+	if stats.TotalBatches > 0 {
+		stats.AverageTxCountPerBatch = float64(stats.TotalTxCount) / float64(stats.TotalBatches)
+		stats.AverageBatchSizeBytes = float64(stats.TotalBytes) / float64(stats.TotalBatches)
+	}
+
+	c.statsCache = stats
+	c.statsAt = time.Now()
+	return stats
+}
+
+func (s BatchMetadataStats) String() string {
+	if s.TotalBatches == 0 {
+		return "batches=0"
+	}
+	return fmt.Sprintf("batches=%d (#%d-#%d) txs=%d bytes=%d avg_txs=%.1f avg_bytes=%.0f",
+		s.TotalBatches, s.OldestBatch.BatchNumber, s.NewestBatch.BatchNumber,
+		s.TotalTxCount, s.TotalBytes, s.AverageTxCountPerBatch, s.AverageBatchSizeBytes)
+}
+
+const dailyCountLayout = "2006-01-02"
+
+type DailyCount struct {
+	Date  string
+	Count int
+}
+
+// GetBatchCountByDay counts stored batches per UTC day of their Timestamp,
+// keyed by date in "2006-01-02" form. Batches without a timestamp are
+// skipped.
+func (c *CDKIntegration) GetBatchCountByDay() map[string]int {
+	counts := make(map[string]int)
+	c.metadataStore.Range(func(metadata *BatchMetadata) bool {
+		if !metadata.Timestamp.IsZero() {
+			counts[metadata.Timestamp.UTC().Format(dailyCountLayout)]++
+		}
+		return true
+	})
+	return counts
+}
+
+// GetBatchCountByDaySorted is GetBatchCountByDay as a slice ordered by date.
+// Days with no batches are not included.
+func (c *CDKIntegration) GetBatchCountByDaySorted() []DailyCount {
+	counts := c.GetBatchCountByDay()
+
+	daily := make([]DailyCount, 0, len(counts))
+	for date, count := range counts {
+		daily = append(daily, DailyCount{Date: date, Count: count})
+	}
+	sort.Slice(daily, func(i, j int) bool {
+		return daily[i].Date < daily[j].Date
+	})
+	return daily
+}
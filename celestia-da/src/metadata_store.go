@@ -0,0 +1,137 @@
+package celestiada
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+// MetadataStore persists batch metadata keyed by batch number. Implementations
+// must be safe for concurrent use.
+type MetadataStore interface {
+	Load(batchNumber uint64) (*BatchMetadata, bool, error)
+	Store(metadata *BatchMetadata) error
+	Delete(batchNumber uint64) error
+	Range(fn func(metadata *BatchMetadata) bool) error
+}
+
+type memoryMetadataStore struct {
+	entries sync.Map
+}
+
+func NewMemoryMetadataStore() MetadataStore {
+	return &memoryMetadataStore{}
+}
+
+func (s *memoryMetadataStore) Load(batchNumber uint64) (*BatchMetadata, bool, error) {
+	value, ok := s.entries.Load(batchNumber)
+	if !ok {
+		return nil, false, nil
+	}
+	return value.(*BatchMetadata), true, nil
+}
+
+func (s *memoryMetadataStore) Store(metadata *BatchMetadata) error {
+	s.entries.Store(metadata.BatchNumber, metadata)
+	return nil
+}
+
+func (s *memoryMetadataStore) Delete(batchNumber uint64) error {
+	s.entries.Delete(batchNumber)
+	return nil
+}
+
+func (s *memoryMetadataStore) Range(fn func(metadata *BatchMetadata) bool) error {
+	s.entries.Range(func(_, value interface{}) bool {
+		return fn(value.(*BatchMetadata))
+	})
+	return nil
+}
+
+// cachedMetadataStore is a read-through LRU cache in front of a slower store.
+// Writes go to both the backing store and the cache.
+type cachedMetadataStore struct {
+	backing  MetadataStore
+	capacity int
+
+	mu      sync.Mutex
+	entries map[uint64]*list.Element
+	order   *list.List
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64
+}
+
+func newCachedMetadataStore(backing MetadataStore, capacity int) *cachedMetadataStore {
+	return &cachedMetadataStore{
+		backing:  backing,
+		capacity: capacity,
+		entries:  make(map[uint64]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (s *cachedMetadataStore) Load(batchNumber uint64) (*BatchMetadata, bool, error) {
+	s.mu.Lock()
+	if elem, ok := s.entries[batchNumber]; ok {
+		s.order.MoveToFront(elem)
+		s.mu.Unlock()
+		s.hits.Add(1)
+		return elem.Value.(*BatchMetadata), true, nil
+	}
+	s.mu.Unlock()
+	s.misses.Add(1)
+
+	metadata, ok, err := s.backing.Load(batchNumber)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+
+	s.put(metadata)
+	return metadata, true, nil
+}
+
+func (s *cachedMetadataStore) Store(metadata *BatchMetadata) error {
+	if err := s.backing.Store(metadata); err != nil {
+		return err
+	}
+
+	s.put(metadata)
+	return nil
+}
+
+func (s *cachedMetadataStore) Delete(batchNumber uint64) error {
+	s.mu.Lock()
+	if elem, ok := s.entries[batchNumber]; ok {
+		s.order.Remove(elem)
+		delete(s.entries, batchNumber)
+	}
+	s.mu.Unlock()
+
+	return s.backing.Delete(batchNumber)
+}
+
+func (s *cachedMetadataStore) Range(fn func(metadata *BatchMetadata) bool) error {
+	return s.backing.Range(fn)
+}
+
+func (s *cachedMetadataStore) put(metadata *BatchMetadata) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[metadata.BatchNumber]; ok {
+		elem.Value = metadata
+		s.order.MoveToFront(elem)
+		return
+	}
+
+	s.entries[metadata.BatchNumber] = s.order.PushFront(metadata)
+
+	for s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*BatchMetadata).BatchNumber)
+		s.evictions.Add(1)
+	}
+}
@@ -0,0 +1,279 @@
+package celestiada
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+const (
+	metadataWatcherBuffer = 64
+
+	// metadataNotifyBuffer is how many stored batches may wait for watchers
+	// and listeners before the workers storing them block.
+	metadataNotifyBuffer = 1024
+
+	// metadataWatchQueueSize is how many live entries BatchMetadataWatchFrom
+	// holds for a consumer before giving up on it.
+	metadataWatchQueueSize = 4096
+)
+
+// BatchMetadataSubscribe returns a channel that receives the metadata of
+// every batch stored from now on, in the order the batches' results are
+// delivered; with Config.StrictOrdering that is batch number order. Delivery
+// follows the same rules as WatchBatchFailures: slow watchers miss entries
+// rather than blocking workers, and the channel is closed when ctx is done or
+// the integration is closed.
+func (c *CDKIntegration) BatchMetadataSubscribe(ctx context.Context) <-chan *BatchMetadata {
+	return c.BatchMetadataWatchFiltered(ctx, nil)
+}
+
+// BatchMetadataWatchFiltered is like BatchMetadataSubscribe but only emits
+// metadata for which filter returns true. Unmatched entries are dropped
+// before they reach the channel and do not count towards its buffer. filter
+// runs on the integration's notification goroutine, shared by every watcher
+// and listener, and must not block.
+func (c *CDKIntegration) BatchMetadataWatchFiltered(ctx context.Context, filter func(*BatchMetadata) bool) <-chan *BatchMetadata {
+	updates := make(chan *BatchMetadata, metadataWatcherBuffer)
+
+	c.metadataSubsMu.Lock()
+	c.metadataSubs[updates] = filter
+	c.metadataSubsMu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-c.ctx.Done():
+		}
+
+		c.metadataSubsMu.Lock()
+		delete(c.metadataSubs, updates)
+		close(updates)
+		c.metadataSubsMu.Unlock()
+	}()
+
+	return updates
+}
+
+// BatchMetadataWatchFrom delivers the stored metadata of every batch
+// numbered fromBatchNumber or higher, in batch number order and migrated to
+// the current schema, followed by metadata stored from then on. Unlike
+// BatchMetadataSubscribe it never drops entries: live metadata is queued
+// until the consumer takes it, up to metadataWatchQueueSize entries. A
+// consumer that falls further behind has its channel closed, and can resume
+// with a new watch from the batch after the last one it received. The live
+// watcher is registered before the store is read, and a stored batch that
+// also arrives live before any higher-numbered live batch is delivered once.
+// The channel is also closed when ctx is done or the integration is closed.
+func (c *CDKIntegration) BatchMetadataWatchFrom(ctx context.Context, fromBatchNumber uint64) <-chan *BatchMetadata {
+	ctx, cancel := context.WithCancel(ctx)
+
+	var queueMu sync.Mutex
+	var queued []*BatchMetadata
+	overflowed := false
+	wake := make(chan struct{}, 1)
+
+	// The filter hands live entries to the queue and returns false, so
+	// nothing reaches the bounded, lossy channel, which only serves to
+	// signal the end of the subscription by closing.
+	closed := c.BatchMetadataWatchFiltered(ctx, func(m *BatchMetadata) bool {
+		if m.BatchNumber < fromBatchNumber {
+			return false
+		}
+		queueMu.Lock()
+		if len(queued) < metadataWatchQueueSize {
+			queued = append(queued, m)
+		} else {
+			overflowed = true
+		}
+		queueMu.Unlock()
+
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+		return false
+	})
+
+	var history []*BatchMetadata
+	c.metadataStore.Range(func(metadata *BatchMetadata) bool {
+		if metadata.BatchNumber < fromBatchNumber {
+			return true
+		}
+		migrated, err := migrateMetadata(metadata)
+		if err != nil {
+			c.logger().Error("skipping stored metadata in watch", "batch", metadata.BatchNumber, "error", err)
+			return true
+		}
+		history = append(history, migrated)
+		return true
+	})
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].BatchNumber < history[j].BatchNumber
+	})
+
+	updates := make(chan *BatchMetadata, metadataWatcherBuffer)
+
+	go func() {
+		defer close(updates)
+		defer cancel()
+
+		send := func(metadata *BatchMetadata) bool {
+			select {
+			case updates <- metadata:
+				return true
+			case <-closed:
+				return false
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		// delivered maps the numbers of stored batches that may still
+		// arrive live to their metadata; unseen holds the same batches in
+		// number order, so that those the live cursor has passed can be
+		// dropped.
+		delivered := make(map[uint64]*BatchMetadata, len(history))
+		for _, metadata := range history {
+			if !send(metadata) {
+				return
+			}
+			delivered[metadata.BatchNumber] = metadata
+		}
+		unseen := history
+		history = nil
+
+		var cursor uint64
+		for {
+			queueMu.Lock()
+			live, overflow := queued, overflowed
+			queued = nil
+			queueMu.Unlock()
+
+			for _, metadata := range live {
+				if seen, ok := delivered[metadata.BatchNumber]; ok {
+					delete(delivered, metadata.BatchNumber)
+					if reflect.DeepEqual(seen, metadata) {
+						continue
+					}
+				}
+				if !send(metadata) {
+					return
+				}
+				if metadata.BatchNumber > cursor {
+					cursor = metadata.BatchNumber
+				}
+			}
+			for len(unseen) > 0 && unseen[0].BatchNumber <= cursor {
+				delete(delivered, unseen[0].BatchNumber)
+				unseen[0] = nil
+				unseen = unseen[1:]
+			}
+
+			if overflow {
+				c.logger().Warn("closing metadata watch that fell behind", "queued", metadataWatchQueueSize)
+				return
+			}
+
+			select {
+			case <-wake:
+			case <-closed:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return updates
+}
+
+// BatchListener receives the metadata of every stored batch, in the same
+// order as BatchMetadataSubscribe. OnBatch is called on the integration's
+// notification goroutine rather than on a worker, one batch at a time, and
+// must not add or remove listeners. A slow listener delays every other
+// watcher and listener, and stalls workers once metadataNotifyBuffer batches
+// are waiting to be notified; slow work belongs behind
+// BatchMetadataSubscribe instead.
+type BatchListener interface {
+	OnBatch(m *BatchMetadata)
+}
+
+// AddBatchListener registers listener under id. It returns ErrListenerExists
+// if id is already in use.
+func (c *CDKIntegration) AddBatchListener(id string, listener BatchListener) error {
+	c.listenersMu.Lock()
+	defer c.listenersMu.Unlock()
+
+	if _, ok := c.listeners[id]; ok {
+		return fmt.Errorf("%w: %q", ErrListenerExists, id)
+	}
+	c.listeners[id] = listener
+	return nil
+}
+
+// RemoveBatchListener unregisters the listener added under id. It returns
+// ErrListenerNotFound if there is none.
+func (c *CDKIntegration) RemoveBatchListener(id string) error {
+	c.listenersMu.Lock()
+	defer c.listenersMu.Unlock()
+
+	if _, ok := c.listeners[id]; !ok {
+		return fmt.Errorf("%w: %q", ErrListenerNotFound, id)
+	}
+	delete(c.listeners, id)
+	return nil
+}
+
+// notifyMetadata queues metadata for watchers and listeners. It blocks only
+// while the notification queue is full, and drops metadata once the
+// integration is shutting down.
+func (c *CDKIntegration) notifyMetadata(metadata *BatchMetadata) {
+	select {
+	case c.notifications <- metadata:
+	case <-c.ctx.Done():
+	}
+}
+
+// dispatchMetadata delivers queued notifications until the integration shuts
+// down, then delivers those already queued and returns.
+func (c *CDKIntegration) dispatchMetadata() {
+	for {
+		select {
+		case metadata := <-c.notifications:
+			c.deliverMetadata(metadata)
+		case <-c.ctx.Done():
+			for {
+				select {
+				case metadata := <-c.notifications:
+					c.deliverMetadata(metadata)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *CDKIntegration) deliverMetadata(metadata *BatchMetadata) {
+	c.metadataSubsMu.Lock()
+	for updates, filter := range c.metadataSubs {
+		if filter != nil && !filter(metadata) {
+			continue
+		}
+		select {
+		case updates <- metadata:
+		default:
+		}
+	}
+	c.metadataSubsMu.Unlock()
+
+	c.listenersMu.RLock()
+	defer c.listenersMu.RUnlock()
+
+	for _, listener := range c.listeners {
+		listener.OnBatch(metadata)
+	}
+}
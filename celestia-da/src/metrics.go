@@ -0,0 +1,31 @@
+package celestiada
+
+import "time"
+
+// MetricsRecorder receives one call per processed batch.
+type MetricsRecorder interface {
+	RecordSubmit(duration time.Duration, success bool, batchNumber uint64)
+}
+
+// AttachMetrics sets the metrics recorder after construction, for example once
+// a Prometheus registry is ready. Only one recorder can be attached.
+func (c *CDKIntegration) AttachMetrics(recorder MetricsRecorder) error {
+	c.metricsMu.Lock()
+	defer c.metricsMu.Unlock()
+
+	if c.metrics != nil {
+		return ErrAlreadyAttached
+	}
+	c.metrics = recorder
+	return nil
+}
+
+func (c *CDKIntegration) recordMetrics(duration time.Duration, success bool, batchNumber uint64) {
+	c.metricsMu.RLock()
+	recorder := c.metrics
+	c.metricsMu.RUnlock()
+
+	if recorder != nil {
+		recorder.RecordSubmit(duration, success, batchNumber)
+	}
+}
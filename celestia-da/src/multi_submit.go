@@ -0,0 +1,64 @@
+package celestiada
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// MultiSubmitParallel spreads payloads over several Blob.Submit calls so they
+// can land at different heights. Payloads are grouped in input order, with
+// each group holding roughly one Config.MaxBlobSize worth of data based on the
+// average payload size, and up to maxConcurrency groups are submitted at
+// once. Results are returned in input order.
+func (p *Publisher) MultiSubmitParallel(ctx context.Context, payloads [][]byte, maxConcurrency int) []BlobSubmitResult {
+	results := make([]BlobSubmitResult, len(payloads))
+	if len(payloads) == 0 {
+		return results
+	}
+	if maxConcurrency <= 0 {
+		maxConcurrency = 1
+	}
+
+	var total uint64
+	for _, payload := range payloads {
+		total += uint64(len(payload))
+	}
+	groupSize := len(payloads)
+	if avgPayloadSize := total / uint64(len(payloads)); avgPayloadSize > 0 {
+		groupSize = int(p.maxBlobSize.Load() / avgPayloadSize)
+	}
+	if groupSize < 1 {
+		groupSize = 1
+	}
+
+	sem := make(chan struct{}, maxConcurrency)
+	var wg sync.WaitGroup
+
+	for start := 0; start < len(payloads); start += groupSize {
+		end := start + groupSize
+		if end > len(payloads) {
+			end = len(payloads)
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				for i := start; i < end; i++ {
+					results[i].Error = fmt.Errorf("failed to submit blobs: %w", ctx.Err())
+				}
+				return
+			}
+
+			copy(results[start:end], p.SubmitBlobs(ctx, payloads[start:end]))
+		}(start, end)
+	}
+
+	wg.Wait()
+	return results
+}
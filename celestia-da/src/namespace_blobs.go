@@ -0,0 +1,459 @@
+package celestiada
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+const (
+	multiGetConcurrency = 4
+
+	capacityWarningRatio = 0.8
+
+	// defaultMaxSquareSize is the governance maximum original square width
+	// on Celestia mainnet, used when Config.MaxSquareSize is not set.
+	defaultMaxSquareSize = 64
+
+	// maxUsageRangeHeights caps the heights NamespaceUsageOverRange scans
+	// in one call; longer ranges must be paged by the caller.
+	maxUsageRangeHeights = 10000
+)
+
+type NamespaceCommitment struct {
+	NamespaceID string
+	Commitment  string
+}
+
+type NamespaceBlobResult struct {
+	Height       uint64
+	Commitment   string
+	Data         []byte
+	ShareVersion uint8
+	Error        error
+}
+
+// GetNamespaceBlobs streams every blob in the publisher's namespace for the
+// inclusive height range. The channel is closed after the last height or the
+// first RPC error, which is delivered as a result with Error set.
+func (p *Publisher) GetNamespaceBlobs(ctx context.Context, fromHeight, toHeight uint64) (<-chan NamespaceBlobResult, error) {
+	if fromHeight == 0 || fromHeight > toHeight {
+		return nil, fmt.Errorf("invalid height range: %d-%d", fromHeight, toHeight)
+	}
+
+	namespace := p.currentNamespace()
+	results := make(chan NamespaceBlobResult, 16)
+
+	go func() {
+		defer close(results)
+
+		for height := fromHeight; height <= toHeight; height++ {
+			rpcStart := time.Now()
+			blobs, err := p.client.Blob.GetAll(ctx, height, []share.Namespace{namespace})
+			p.traceRPC("Blob.GetAll", rpcStart, err)
+			if err != nil && !isBlobNotFound(err) {
+				select {
+				case results <- NamespaceBlobResult{
+					Height: height,
+					Error:  fmt.Errorf("failed to get blobs at height %d: %w", height, err),
+				}:
+				case <-ctx.Done():
+				}
+				return
+			}
+
+			for _, b := range blobs {
+				select {
+				case results <- NamespaceBlobResult{
+					Height:       height,
+					Commitment:   hex.EncodeToString(b.Commitment),
+					Data:         b.Data,
+					ShareVersion: uint8(b.ShareVersion),
+				}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return results, nil
+}
+
+// GetBlobsInDateRange streams the namespace blobs included in blocks whose
+// header time falls within [from, to]. The height range is located by binary
+// search over header timestamps, which only relies on block times being
+// monotonic and so holds even when the block interval changes in the range.
+func (p *Publisher) GetBlobsInDateRange(ctx context.Context, from, to time.Time) (<-chan NamespaceBlobResult, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("invalid time range: %s is before %s", to, from)
+	}
+
+	rpcStart := time.Now()
+	head, err := p.client.Header.NetworkHead(ctx)
+	p.traceRPC("Header.NetworkHead", rpcStart, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get network head: %w", err)
+	}
+	headHeight := head.Height()
+
+	fromHeight, err := p.searchHeight(ctx, headHeight, func(t time.Time) bool {
+		return !t.Before(from)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	toHeight, err := p.searchHeight(ctx, headHeight, func(t time.Time) bool {
+		return t.After(to)
+	})
+	if err != nil {
+		return nil, err
+	}
+	toHeight--
+
+	if fromHeight > headHeight || fromHeight > toHeight {
+		results := make(chan NamespaceBlobResult)
+		close(results)
+		return results, nil
+	}
+
+	return p.GetNamespaceBlobs(ctx, fromHeight, toHeight)
+}
+
+// searchHeight returns the lowest height in [1, headHeight] whose header time
+// satisfies pred, or headHeight+1 if none does. pred must be monotonic.
+func (p *Publisher) searchHeight(ctx context.Context, headHeight uint64, pred func(time.Time) bool) (uint64, error) {
+	low, high := uint64(1), headHeight+1
+	for low < high {
+		mid := low + (high-low)/2
+
+		rpcStart := time.Now()
+		header, err := p.client.Header.GetByHeight(ctx, mid)
+		p.traceRPC("Header.GetByHeight", rpcStart, err)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get header at height %d: %w", mid, err)
+		}
+
+		if pred(header.Time()) {
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+	return low, nil
+}
+
+func isBlobNotFound(err error) bool {
+	return strings.Contains(err.Error(), "blob: not found")
+}
+
+// MultiGet fetches blobs from several namespaces at one height. Requests are
+// grouped by namespace and each namespace is queried in parallel, at most
+// multiGetConcurrency at a time. Results are returned in input order.
+func (p *Publisher) MultiGet(ctx context.Context, height uint64, namespaceCommitments []NamespaceCommitment) ([][]byte, error) {
+	groups := make(map[string][]int)
+	for i, nc := range namespaceCommitments {
+		groups[nc.NamespaceID] = append(groups[nc.NamespaceID], i)
+	}
+
+	results := make([][]byte, len(namespaceCommitments))
+	errs := make(chan error, len(groups))
+	sem := make(chan struct{}, multiGetConcurrency)
+	var wg sync.WaitGroup
+
+	for namespaceID, indexes := range groups {
+		wg.Add(1)
+		go func(namespaceID string, indexes []int) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+
+			if err := p.getNamespaceGroup(ctx, height, namespaceID, indexes, namespaceCommitments, results); err != nil {
+				errs <- err
+			}
+		}(namespaceID, indexes)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (p *Publisher) getNamespaceGroup(ctx context.Context, height uint64, namespaceID string, indexes []int, requests []NamespaceCommitment, results [][]byte) error {
+	namespace, err := parseNamespaceID(namespaceID)
+	if err != nil {
+		return fmt.Errorf("namespace %q: %w", namespaceID, err)
+	}
+
+	if len(indexes) == 1 {
+		i := indexes[0]
+		commitment, err := hex.DecodeString(requests[i].Commitment)
+		if err != nil {
+			return fmt.Errorf("invalid commitment %q: %w", requests[i].Commitment, err)
+		}
+
+		rpcStart := time.Now()
+		b, err := p.client.Blob.Get(ctx, height, namespace, commitment)
+		p.traceRPC("Blob.Get", rpcStart, err)
+		if err != nil {
+			return fmt.Errorf("failed to get blob %s in namespace %s: %w", requests[i].Commitment, namespaceID, err)
+		}
+		results[i] = b.Data
+		return nil
+	}
+
+	rpcStart := time.Now()
+	blobs, err := p.client.Blob.GetAll(ctx, height, []share.Namespace{namespace})
+	p.traceRPC("Blob.GetAll", rpcStart, err)
+	if err != nil {
+		return fmt.Errorf("failed to get blobs in namespace %s: %w", namespaceID, err)
+	}
+
+	byCommitment := make(map[string][]byte, len(blobs))
+	for _, b := range blobs {
+		byCommitment[hex.EncodeToString(b.Commitment)] = b.Data
+	}
+
+	for _, i := range indexes {
+		data, ok := byCommitment[strings.ToLower(requests[i].Commitment)]
+		if !ok {
+			return fmt.Errorf("blob %s not found in namespace %s at height %d", requests[i].Commitment, namespaceID, height)
+		}
+		results[i] = data
+	}
+	return nil
+}
+
+// ValidateNamespaceCapacity compares the namespace's share usage in the latest
+// block with the largest square the network allows, Config.MaxSquareSize
+// (64 if unset). The latest block's own square is not used, since blocks
+// grow to fit their data and a small block says nothing about the room left.
+// available is the unused capacity in bytes; warning is set when adding
+// proposedSize would push usage above 80% of the maximum square.
+func (p *Publisher) ValidateNamespaceCapacity(ctx context.Context, proposedSize uint64) (available uint64, warning bool, err error) {
+	squareSize := p.config.MaxSquareSize
+	if squareSize == 0 {
+		squareSize = defaultMaxSquareSize
+	}
+	capacity := squareSize * squareSize * shareSize
+
+	height, err := p.networkHead(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+
+	rpcStart := time.Now()
+	blobs, err := p.client.Blob.GetAll(ctx, height, []share.Namespace{p.currentNamespace()})
+	p.traceRPC("Blob.GetAll", rpcStart, err)
+	if err != nil && !isBlobNotFound(err) {
+		return 0, false, fmt.Errorf("failed to get namespace blobs at height %d: %w", height, err)
+	}
+
+	var used uint64
+	for _, b := range blobs {
+		shares, err := sparseSharesNeeded(len(b.Data), uint8(b.ShareVersion))
+		if err != nil {
+			return 0, false, err
+		}
+		used += uint64(shares) * shareSize
+	}
+
+	var proposed uint64
+	if proposedSize > 0 {
+		shares, err := sparseSharesNeeded(int(proposedSize), share.DefaultShareVersion)
+		if err != nil {
+			return 0, false, err
+		}
+		proposed = uint64(shares) * shareSize
+	}
+
+	if used < capacity {
+		available = capacity - used
+	}
+	warning = float64(used+proposed) > float64(capacity)*capacityWarningRatio
+
+	return available, warning, nil
+}
+
+// ListNamespaces returns the blob namespaces present at height, sorted by
+// namespace bytes. The blob API cannot query every namespace at once, so this
+// downloads the extended data square and reads the namespace of each share in
+// the original quadrant. That is O(square_size) in both bandwidth and time, so
+// it is meant for tooling and must not be called on the hot path.
+func (p *Publisher) ListNamespaces(ctx context.Context, height uint64) ([]share.Namespace, error) {
+	rpcStart := time.Now()
+	header, err := p.client.Header.GetByHeight(ctx, height)
+	p.traceRPC("Header.GetByHeight", rpcStart, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get header at height %d: %w", height, err)
+	}
+
+	rpcStart = time.Now()
+	eds, err := p.client.Share.GetEDS(ctx, header.DAH)
+	p.traceRPC("Share.GetEDS", rpcStart, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data square at height %d: %w", height, err)
+	}
+
+	seen := make(map[string]bool)
+	var namespaces []share.Namespace
+
+	squareSize := eds.Width() / 2
+	for row := uint(0); row < squareSize; row++ {
+		shares := eds.Row(row)
+		for col := uint(0); col < squareSize && col < uint(len(shares)); col++ {
+			if len(shares[col]) < namespaceSize {
+				continue
+			}
+
+			ns := shares[col][:namespaceSize]
+			if isReservedNamespace(ns) || seen[string(ns)] {
+				continue
+			}
+			seen[string(ns)] = true
+			namespaces = append(namespaces, share.Namespace(append([]byte(nil), ns...)))
+		}
+	}
+
+	sort.Slice(namespaces, func(i, j int) bool {
+		return bytes.Compare(namespaces[i], namespaces[j]) < 0
+	})
+
+	return namespaces, nil
+}
+
+// isReservedNamespace reports whether ns is one of Celestia's reserved
+// namespaces: primary reserved (version 0, all-zero ID apart from the last
+// byte) or secondary reserved (version 255, used for padding and parity).
+func isReservedNamespace(ns []byte) bool {
+	if ns[0] == 0xFF {
+		return true
+	}
+	if ns[0] != 0 {
+		return false
+	}
+	for _, b := range ns[1 : namespaceSize-1] {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+type HeightUsage struct {
+	Height     uint64
+	BlobCount  int
+	TotalBytes uint64
+}
+
+// NamespaceUsageAtHeight returns the number of blobs the publisher's namespace
+// has at height and their combined data size. A height with no blobs reports
+// zero usage rather than an error.
+func (p *Publisher) NamespaceUsageAtHeight(ctx context.Context, height uint64) (blobCount int, totalBytes uint64, err error) {
+	rpcStart := time.Now()
+	blobs, err := p.client.Blob.GetAll(ctx, height, []share.Namespace{p.currentNamespace()})
+	p.traceRPC("Blob.GetAll", rpcStart, err)
+	if err != nil && !isBlobNotFound(err) {
+		return 0, 0, fmt.Errorf("failed to get blobs at height %d: %w", height, err)
+	}
+
+	for _, b := range blobs {
+		totalBytes += uint64(len(b.Data))
+	}
+	return len(blobs), totalBytes, nil
+}
+
+// NamespaceUsageOverRange returns the namespace usage for every height in the
+// inclusive range, one entry per height including empty ones, so the result
+// can be plotted directly as a time series. At most 10000 heights can be
+// requested per call; page through longer ranges.
+func (p *Publisher) NamespaceUsageOverRange(ctx context.Context, from, to uint64) ([]HeightUsage, error) {
+	if from == 0 || from > to {
+		return nil, fmt.Errorf("invalid height range: %d-%d", from, to)
+	}
+	if to-from >= maxUsageRangeHeights {
+		return nil, fmt.Errorf("height range %d-%d spans more than %d heights", from, to, maxUsageRangeHeights)
+	}
+
+	usage := make([]HeightUsage, 0, to-from+1)
+	for height := from; ; height++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		count, size, err := p.NamespaceUsageAtHeight(ctx, height)
+		if err != nil {
+			return nil, err
+		}
+		usage = append(usage, HeightUsage{Height: height, BlobCount: count, TotalBytes: size})
+
+		if height == to {
+			return usage, nil
+		}
+	}
+}
+
+type BlobEntry struct {
+	Commitment   string
+	Data         []byte
+	ShareVersion uint8
+}
+
+// GetAllNamespaceBlobs returns every blob at height keyed by hex-encoded
+// namespace. It finds the namespaces with ListNamespaces, so it downloads the
+// full extended data square before fetching any blob; this is far more
+// expensive than a namespace query and is only meant for inspecting blocks
+// from tooling. Calls fail with ErrFullBlockFetchDisabled unless
+// Config.AllowFullBlockFetch is set.
+func (p *Publisher) GetAllNamespaceBlobs(ctx context.Context, height uint64) (map[string][]*BlobEntry, error) {
+	if !p.config.AllowFullBlockFetch {
+		return nil, ErrFullBlockFetchDisabled
+	}
+
+	namespaces, err := p.ListNamespaces(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]*BlobEntry, len(namespaces))
+	for _, namespace := range namespaces {
+		rpcStart := time.Now()
+		blobs, err := p.client.Blob.GetAll(ctx, height, []share.Namespace{namespace})
+		p.traceRPC("Blob.GetAll", rpcStart, err)
+		if err != nil && !isBlobNotFound(err) {
+			return nil, fmt.Errorf("failed to get blobs in namespace %x at height %d: %w", []byte(namespace), height, err)
+		}
+		if len(blobs) == 0 {
+			continue
+		}
+
+		entries := make([]*BlobEntry, len(blobs))
+		for i, b := range blobs {
+			entries[i] = &BlobEntry{
+				Commitment:   hex.EncodeToString(b.Commitment),
+				Data:         b.Data,
+				ShareVersion: uint8(b.ShareVersion),
+			}
+		}
+		result[hex.EncodeToString(namespace)] = entries
+	}
+	return result, nil
+}
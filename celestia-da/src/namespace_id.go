@@ -0,0 +1,96 @@
+package celestiada
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+const (
+	namespaceVersionZero           = 0
+	namespaceVersionZeroIDSize     = 10
+	namespaceVersionZeroPrefixSize = namespaceSize - 1 - namespaceVersionZeroIDSize
+)
+
+// NamespaceIDFromString derives a 10-byte version 0 namespace ID from a
+// human-readable name such as "zkfair-mainnet", by truncating its SHA-256
+// hash, and returns it hex encoded for use as Config.NamespaceID. The
+// derivation is a convention of this package, not a Celestia standard: other
+// tools may map the same name differently, and distinct names can in
+// principle collide.
+func NamespaceIDFromString(s string) (string, error) {
+	if s == "" {
+		return "", fmt.Errorf("namespace name is empty")
+	}
+
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:namespaceVersionZeroIDSize]), nil
+}
+
+// parseNamespaceID decodes a hex namespace. A 10-byte version 0 ID is
+// expanded to the full namespace and a full 29-byte namespace is used as
+// given; any other length is rejected.
+func parseNamespaceID(namespaceID string) (share.Namespace, error) {
+	id, err := hex.DecodeString(namespaceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace ID: %w", err)
+	}
+	if len(id) == namespaceSize {
+		return share.Namespace(id), nil
+	}
+	if len(id) != namespaceVersionZeroIDSize {
+		return nil, fmt.Errorf("invalid namespace ID: %d bytes, want %d (or a full %d-byte namespace)",
+			len(id), namespaceVersionZeroIDSize, namespaceSize)
+	}
+
+	namespace := make([]byte, 0, namespaceSize)
+	namespace = append(namespace, namespaceVersionZero)
+	namespace = append(namespace, make([]byte, namespaceVersionZeroPrefixSize)...)
+	namespace = append(namespace, id...)
+	return share.Namespace(namespace), nil
+}
+
+// PaddingMode selects how NewPublisherWithPaddedNamespace pads a short
+// namespace ID to 10 bytes.
+type PaddingMode int
+
+const (
+	// PadLeft prepends zero bytes. This is how Celestia itself pads version
+	// 0 namespace IDs, so it yields the namespace other tools will use.
+	PadLeft PaddingMode = iota
+	// PadRight appends zero bytes. The result is a different namespace from
+	// the one Celestia tooling derives from the same short ID.
+	PadRight
+)
+
+// NewPublisherWithPaddedNamespace is NewPublisher for test setups that use
+// short namespace IDs. Config.NamespaceID may be hex or, if it is not valid
+// hex, plain text such as "test"; either way it may be at most 10 bytes and
+// is zero-padded to 10 bytes according to padding. It is a test convenience
+// only: the padding must match the Celestia spec (PadLeft) for the namespace
+// to agree with other tools, and production code should call NewPublisher
+// with an exact 10-byte ID.
+func NewPublisherWithPaddedNamespace(config Config, padding PaddingMode) (*Publisher, error) {
+	id, err := hex.DecodeString(config.NamespaceID)
+	if err != nil {
+		id = []byte(config.NamespaceID)
+	}
+	if len(id) == 0 || len(id) > namespaceVersionZeroIDSize {
+		return nil, fmt.Errorf("invalid namespace ID %q: must be 1 to %d bytes", config.NamespaceID, namespaceVersionZeroIDSize)
+	}
+
+	padded := make([]byte, namespaceVersionZeroIDSize)
+	switch padding {
+	case PadLeft:
+		copy(padded[namespaceVersionZeroIDSize-len(id):], id)
+	case PadRight:
+		copy(padded, id)
+	default:
+		return nil, fmt.Errorf("invalid padding mode %d", padding)
+	}
+
+	config.NamespaceID = hex.EncodeToString(padded)
+	return NewPublisher(config)
+}
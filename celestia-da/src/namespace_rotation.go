@@ -0,0 +1,45 @@
+package celestiada
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+// SetNamespace switches the namespace new batches are published to. Batch
+// processing is stopped while the namespace changes, so every batch is
+// published and recorded entirely under one namespace; processing the caller
+// suspended with SuspendProcessing stays suspended. Existing metadata
+// keeps the namespace it was published under and remains retrievable. The
+// publisher holds the current namespace, guarded by its own lock; the
+// integration's copy of Config.NamespaceID keeps its initial value.
+func (c *CDKIntegration) SetNamespace(ctx context.Context, newNamespaceID string) error {
+	if _, err := parseNamespaceID(newNamespaceID); err != nil {
+		return err
+	}
+
+	c.holdProcessing()
+	defer c.releaseProcessing()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return c.publisher.setNamespace(newNamespaceID)
+}
+
+// batchNamespace returns the namespace a batch was published under. Metadata
+// written before namespaces were recorded uses the current namespace.
+func (c *CDKIntegration) batchNamespace(metadata *BatchMetadata) (share.Namespace, error) {
+	if metadata.Namespace == "" {
+		return c.publisher.currentNamespace(), nil
+	}
+
+	namespace, err := hex.DecodeString(metadata.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace for batch %d: %w", metadata.BatchNumber, err)
+	}
+	return share.Namespace(namespace), nil
+}
@@ -0,0 +1,299 @@
+package celestiada
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+	"github.com/celestiaorg/nmt"
+	"github.com/celestiaorg/nmt/namespace"
+)
+
+// NMTProof proves that Data, a blob, is included under a namespace. A blob
+// can span several rows of the data square, so it carries one row proof per
+// row the blob occupies. NamespaceRowRoots holds the NMT root of every row
+// of the block at Height that contains the namespace, in square order, and
+// the blob's rows are those starting at index FirstRow.
+type NMTProof struct {
+	Height            uint64
+	Namespace         []byte
+	ShareVersion      uint8
+	Data              []byte
+	Rows              []NMTRowProof
+	NamespaceRowRoots [][]byte
+	FirstRow          int
+}
+
+// NMTRowProof is the proof for one row. Start and End are the blob's leaf
+// (share) indices within the row, end exclusive.
+type NMTRowProof struct {
+	Start int
+	End   int
+	Nodes [][]byte
+}
+
+// NamespaceTreeProof fetches the inclusion proof for a blob in the
+// publisher's current namespace and pairs it with the blob and the row roots
+// it proves against from the block header, so it can be checked with
+// VerifyNMTProof without access to a node. Use CDKIntegration.BatchNMTProof for batches
+// that may have been published under an earlier namespace.
+func (p *Publisher) NamespaceTreeProof(ctx context.Context, height uint64, commitment string) (*NMTProof, error) {
+	return p.namespaceTreeProof(ctx, p.currentNamespace(), height, commitment)
+}
+
+// BatchNMTProof returns the NMT proof for a published batch, built under the
+// namespace the batch was published to.
+func (c *CDKIntegration) BatchNMTProof(ctx context.Context, batchNumber uint64) (*NMTProof, error) {
+	metadata, err := c.GetBatchMetadata(batchNumber)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.DALayer == DALayerFallback {
+		return nil, fmt.Errorf("batch %d was published to the fallback DA layer", batchNumber)
+	}
+
+	namespace, err := c.batchNamespace(metadata)
+	if err != nil {
+		return nil, err
+	}
+	return c.publisher.namespaceTreeProof(ctx, namespace, metadata.CelestiaHeight, metadata.Commitment)
+}
+
+func (p *Publisher) namespaceTreeProof(ctx context.Context, namespace share.Namespace, height uint64, commitment string) (*NMTProof, error) {
+	commitmentBytes, err := hex.DecodeString(commitment)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commitment: %w", err)
+	}
+
+	rpcStart := time.Now()
+	proof, err := p.client.Blob.GetProof(ctx, height, namespace, commitmentBytes)
+	p.traceRPC("Blob.GetProof", rpcStart, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proof for blob at height %d: %w", height, err)
+	}
+	if proof == nil || len(*proof) == 0 {
+		return nil, fmt.Errorf("empty proof for blob at height %d", height)
+	}
+
+	b, err := p.getBlob(ctx, namespace, height, commitment, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	rpcStart = time.Now()
+	header, err := p.client.Header.GetByHeight(ctx, height)
+	p.traceRPC("Header.GetByHeight", rpcStart, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get header at height %d: %w", height, err)
+	}
+	if header.DAH == nil || len(header.DAH.RowRoots) == 0 {
+		return nil, fmt.Errorf("header at height %d has no data availability header", height)
+	}
+
+	result := &NMTProof{
+		Height:       height,
+		Namespace:    []byte(namespace),
+		ShareVersion: uint8(b.ShareVersion),
+		Data:         b.Data,
+		Rows:         make([]NMTRowProof, len(*proof)),
+	}
+	for i, row := range *proof {
+		result.Rows[i] = NMTRowProof{
+			Start: row.Start(),
+			End:   row.End(),
+			Nodes: row.Nodes(),
+		}
+	}
+
+	for _, root := range header.DAH.RowRoots {
+		if rowContainsNamespace(root, namespace) {
+			result.NamespaceRowRoots = append(result.NamespaceRowRoots, root)
+		}
+	}
+
+	// The proof does not say which rows the blob starts in, so try each
+	// run of the namespace's rows until one verifies.
+	for first := 0; first+len(result.Rows) <= len(result.NamespaceRowRoots); first++ {
+		result.FirstRow = first
+		if verifyNMTRows(result) {
+			return result, nil
+		}
+	}
+	return nil, fmt.Errorf("proof for blob %s at height %d does not match the header's row roots", commitment, height)
+}
+
+// VerifyNMTProof reports whether proof proves proof.Data under
+// proof.Namespace against namespaceRoot, the value GetNamespaceDataRoot
+// returns for the namespace at proof.Height, obtained from a node or header
+// the caller trusts. The proof's row roots must all contain the namespace and
+// digest to namespaceRoot, and each row proof must prove its run of the
+// blob's shares against the matching row root, using the NMT hashing
+// Celestia uses. Only share version 0 blobs can be verified.
+func VerifyNMTProof(proof *NMTProof, namespaceRoot []byte) bool {
+	if proof == nil || len(namespaceRoot) == 0 || len(proof.NamespaceRowRoots) == 0 {
+		return false
+	}
+	for _, root := range proof.NamespaceRowRoots {
+		if !rowContainsNamespace(root, proof.Namespace) {
+			return false
+		}
+	}
+	if !bytes.Equal(namespaceRootOf(proof.NamespaceRowRoots), namespaceRoot) {
+		return false
+	}
+	return verifyNMTRows(proof)
+}
+
+// verifyNMTRows checks proof's row proofs against its row roots, without
+// checking that the row roots can be trusted.
+func verifyNMTRows(proof *NMTProof) bool {
+	if proof == nil || len(proof.Rows) == 0 || proof.FirstRow < 0 ||
+		proof.FirstRow+len(proof.Rows) > len(proof.NamespaceRowRoots) {
+		return false
+	}
+	rowRoots := proof.NamespaceRowRoots[proof.FirstRow:]
+
+	shares, err := splitBlobShares(share.Namespace(proof.Namespace), proof.Data, proof.ShareVersion)
+	if err != nil {
+		return false
+	}
+
+	for i, row := range proof.Rows {
+		if row.Start < 0 || row.End <= row.Start {
+			return false
+		}
+		count := row.End - row.Start
+		if count > len(shares) {
+			return false
+		}
+
+		rowProof := nmt.NewInclusionProof(row.Start, row.End, row.Nodes, true)
+		if !rowProof.VerifyInclusion(sha256.New(), namespace.ID(proof.Namespace), shares[:count], rowRoots[i]) {
+			return false
+		}
+		shares = shares[count:]
+	}
+	return len(shares) == 0
+}
+
+// maxNamespaceRootEntries bounds the namespace data roots GetNamespaceDataRoot
+// keeps; the least recently used height is evicted first.
+const maxNamespaceRootEntries = 1024
+
+// nsRootKey keys the namespace data root cache; the namespace is part of the
+// key because it can be changed with SetNamespace.
+type nsRootKey struct {
+	height    uint64
+	namespace string
+}
+
+// nsRootCache is a least recently used cache of namespace data roots.
+type nsRootCache struct {
+	mu      sync.Mutex
+	entries map[nsRootKey][]byte
+	order   []nsRootKey
+}
+
+func (c *nsRootCache) store(key nsRootKey, root []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[nsRootKey][]byte)
+	}
+	if _, ok := c.entries[key]; ok {
+		c.touch(key)
+	} else {
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = root
+
+	for len(c.order) > maxNamespaceRootEntries {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+func (c *nsRootCache) load(key nsRootKey) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	root, ok := c.entries[key]
+	if ok {
+		c.touch(key)
+	}
+	return root, ok
+}
+
+// touch moves key to the most recently used end of order.
+func (c *nsRootCache) touch(key nsRootKey) {
+	for i, k := range c.order {
+		if k == key {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+	c.order = append(c.order, key)
+}
+
+// GetNamespaceDataRoot returns a digest of the publisher's current namespace
+// at height: the SHA-256 of the concatenated row roots whose namespace range
+// contains it. It is not an NMT root, but it commits to every row holding the
+// namespace, so it is what VerifyNMTProof checks a proof's row roots against.
+// Results for the most recently used heights are cached, as a block's roots
+// never change.
+func (p *Publisher) GetNamespaceDataRoot(ctx context.Context, height uint64) ([]byte, error) {
+	namespace := p.currentNamespace()
+	key := nsRootKey{height: height, namespace: string(namespace)}
+	if root, ok := p.nsRoots.load(key); ok {
+		return append([]byte(nil), root...), nil
+	}
+
+	rpcStart := time.Now()
+	header, err := p.client.Header.GetByHeight(ctx, height)
+	p.traceRPC("Header.GetByHeight", rpcStart, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get header at height %d: %w", height, err)
+	}
+	if header.DAH == nil || len(header.DAH.RowRoots) == 0 {
+		return nil, fmt.Errorf("header at height %d has no data availability header", height)
+	}
+
+	var rowRoots [][]byte
+	for _, root := range header.DAH.RowRoots {
+		if rowContainsNamespace(root, namespace) {
+			rowRoots = append(rowRoots, root)
+		}
+	}
+	if len(rowRoots) == 0 {
+		return nil, fmt.Errorf("namespace %x has no data at height %d", []byte(namespace), height)
+	}
+
+	root := namespaceRootOf(rowRoots)
+	p.nsRoots.store(key, root)
+	return append([]byte(nil), root...), nil
+}
+
+func namespaceRootOf(rowRoots [][]byte) []byte {
+	h := sha256.New()
+	for _, root := range rowRoots {
+		h.Write(root)
+	}
+	return h.Sum(nil)
+}
+
+// rowContainsNamespace reports whether namespace lies within the range of an
+// NMT row root, which is encoded as minNamespace || maxNamespace || hash.
+func rowContainsNamespace(root, namespace []byte) bool {
+	if len(root) < 2*namespaceSize || len(namespace) != namespaceSize {
+		return false
+	}
+
+	min, max := root[:namespaceSize], root[namespaceSize:2*namespaceSize]
+	return bytes.Compare(min, namespace) <= 0 && bytes.Compare(namespace, max) <= 0
+}
@@ -0,0 +1,35 @@
+package celestiada
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+)
+
+const NonceSize = 16
+
+// GenerateNonce returns NonceSize cryptographically random bytes for use
+// with PublishBatchWithNonce.
+func GenerateNonce() []byte {
+	nonce := make([]byte, NonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		panic(fmt.Sprintf("celestiada: failed to read random nonce: %v", err))
+	}
+	return nonce
+}
+
+// PublishBatchWithNonce publishes nonce followed by data, so the commitment
+// depends only on the caller's inputs and test fixtures can be reproduced.
+// The nonce must be NonceSize bytes. RetrieveBatch returns the blob with the
+// nonce still prepended.
+func (p *Publisher) PublishBatchWithNonce(ctx context.Context, data []byte, nonce []byte) (string, error) {
+	if len(nonce) != NonceSize {
+		return "", fmt.Errorf("invalid nonce length: %d, want %d", len(nonce), NonceSize)
+	}
+
+	blobData := make([]byte, 0, NonceSize+len(data))
+	blobData = append(blobData, nonce...)
+	blobData = append(blobData, data...)
+
+	return p.PublishBatch(ctx, blobData)
+}
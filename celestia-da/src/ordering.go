@@ -0,0 +1,54 @@
+package celestiada
+
+import "sync"
+
+// trackOrder registers a dequeued batch as in flight so that higher-numbered
+// batches finishing before it wait for its result to be emitted first.
+func (c *CDKIntegration) trackOrder(batchNumber uint64) {
+	c.orderMu.Lock()
+	defer c.orderMu.Unlock()
+
+	c.inFlight[batchNumber] = sync.NewCond(&c.orderMu)
+}
+
+// awaitTurn blocks until no lower-numbered batch is still in flight.
+func (c *CDKIntegration) awaitTurn(batchNumber uint64) {
+	c.orderMu.Lock()
+	defer c.orderMu.Unlock()
+
+	for {
+		var earlier *sync.Cond
+		for number, cond := range c.inFlight {
+			if number < batchNumber {
+				earlier = cond
+				break
+			}
+		}
+		if earlier == nil {
+			return
+		}
+		earlier.Wait()
+	}
+}
+
+func (c *CDKIntegration) confirmOrder(batchNumber uint64) {
+	c.orderMu.Lock()
+	defer c.orderMu.Unlock()
+
+	cond, ok := c.inFlight[batchNumber]
+	if !ok {
+		return
+	}
+	delete(c.inFlight, batchNumber)
+
+	if batchNumber > c.lastConfirmed.Load() {
+		c.lastConfirmed.Store(batchNumber)
+	}
+	cond.Broadcast()
+}
+
+// LastConfirmedBatch returns the highest batch number whose result has been
+// emitted under strict ordering.
+func (c *CDKIntegration) LastConfirmedBatch() uint64 {
+	return c.lastConfirmed.Load()
+}
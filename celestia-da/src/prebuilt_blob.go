@@ -0,0 +1,82 @@
+package celestiada
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+)
+
+// SubmitBlob submits a blob built by the caller, for cases the publisher's
+// own blob construction does not cover, such as a non-default share version.
+// The blob must be in the publisher's current namespace and within the max
+// blob size. It returns the blob's ref ID.
+func (p *Publisher) SubmitBlob(ctx context.Context, b *blob.Blob) (string, error) {
+	refIDs, err := p.SubmitPrebuiltBlobs(ctx, []*blob.Blob{b})
+	if err != nil {
+		return "", err
+	}
+	return refIDs[0], nil
+}
+
+// SubmitPrebuiltBlobs submits caller-built blobs in a single Blob.Submit call
+// and returns their ref IDs in input order. Every blob is validated before
+// anything is submitted, so one invalid blob fails the whole call.
+func (p *Publisher) SubmitPrebuiltBlobs(ctx context.Context, blobs []*blob.Blob) ([]string, error) {
+	if len(blobs) == 0 {
+		return nil, fmt.Errorf("no blobs to submit")
+	}
+
+	namespace := p.currentNamespace()
+	for i, b := range blobs {
+		if b == nil {
+			return nil, fmt.Errorf("blob %d is nil", i)
+		}
+		if !bytes.Equal(b.Namespace, namespace) {
+			return nil, fmt.Errorf("blob %d namespace %x does not match publisher namespace %x", i, []byte(b.Namespace), []byte(namespace))
+		}
+		if err := p.checkBlobSize(b.Data); err != nil {
+			return nil, fmt.Errorf("blob %d: %w", i, err)
+		}
+	}
+
+	submitCtx, cancel := context.WithTimeout(ctx, p.config.SubmitTimeout)
+	defer cancel()
+
+	pc := p.nextClient()
+	rpcStart := time.Now()
+	height, err := pc.client.Blob.Submit(submitCtx, blobs, &blob.SubmitOptions{
+		GasPrice: p.config.GasPrice,
+	})
+	p.traceRPC("Blob.Submit", rpcStart, err)
+	if err != nil {
+		if isRateLimited(err) {
+			pc.coolDown(p.tokenCooldown())
+		}
+		return nil, fmt.Errorf("failed to submit blobs: %w", err)
+	}
+
+	for _, b := range blobs {
+		p.recordGas(b.Data, p.config.GasPrice)
+	}
+
+	if err := p.awaitSampling(ctx, height); err != nil {
+		return nil, fmt.Errorf("blobs submitted at height %d but not verified: %w", height, err)
+	}
+
+	refIDs := make([]string, len(blobs))
+	for i, b := range blobs {
+		commitment := b.Commitment
+		if len(commitment) == 0 {
+			commitment, err = blob.CreateCommitment(b)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create commitment: %w", err)
+			}
+		}
+		refIDs[i] = fmt.Sprintf("%d:%s", height, hex.EncodeToString(commitment))
+	}
+	return refIDs, nil
+}
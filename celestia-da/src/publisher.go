@@ -1,9 +1,17 @@
 package celestiada
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
 	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/celestiaorg/celestia-openrpc/types/blob"
@@ -12,68 +20,563 @@ import (
 )
 
 type Config struct {
-	Endpoint     string
-	NamespaceID  string
-	AuthToken    string
-	GasPrice     float64
-	MaxBlobSize  uint64
-	SubmitTimeout time.Duration
+	Endpoint                     string
+	NamespaceID                  string
+	AuthToken                    string
+	GasPrice                     float64
+	MaxBlobSize                  uint64
+	SubmitTimeout                time.Duration
+	StrictOrdering               bool
+	WorkerCount                  int
+	MetadataStore                MetadataStore
+	MetadataCacheSize            int
+	TailBufferSize               int
+	AutoCompactAfterBatches      int
+	DefaultConfirmations         uint64
+	AuthTokens                   []string
+	TokenCooldownDuration        time.Duration
+	MinServerVersion             string
+	StrictVersionCheck           bool
+	MaxRetries                   int
+	RetryDelay                   time.Duration
+	OnError                      func(batchNumber uint64, err error, retryCount int)
+	SubscribeReconnectDelay      time.Duration
+	MaxSubscribeReconnects       int
+	BatchQueueTimeout            time.Duration
+	MaxSnapshotBatches           int
+	HeartbeatInterval            time.Duration
+	RetrieveTimeout              time.Duration
+	RateWindowSeconds            int
+	VerificationMode             string
+	DASSamplingTimeout           time.Duration
+	UTIAUSDPriceFeed             func(ctx context.Context) (float64, error)
+	HeightTargetingTimeout       time.Duration
+	RetentionPolicy              BatchRetentionPolicy
+	EvictionCheckInterval        int
+	Logger                       *slog.Logger
+	AllowFullBlockFetch          bool
+	UnhealthyAlertRepeatInterval time.Duration
+	RPCTracer                    func(method string, duration time.Duration, err error)
+	HeightCacheTTL               time.Duration
+	PriorityGasMultiplier        float64
+	AuditLogPath                 string
+	MaxSquareSize                uint64
+	Compression                  string
 }
 
+const (
+	finalityPollInterval = 2 * time.Second
+
+	defaultPriorityGasMultiplier = 2
+)
+
 type Publisher struct {
 	client      *client.Client
+	namespaceMu sync.RWMutex
 	namespace   share.Namespace
 	config      Config
+	maxBlobSize atomic.Uint64
+	largeBlobs  largeBlobCache
+	pool        []*pooledClient
+	poolNext    atomic.Uint64
+	fallbackMu  sync.RWMutex
+	fallback    FallbackDA
+	unhealthy   atomic.Bool
+	nsRoots     nsRootCache
+
+	gasHistoryMu   sync.Mutex
+	gasHistory     []GasRecord
+	gasHistoryNext int
+
+	tipMu     sync.Mutex
+	tipHeight uint64
+	tipAt     time.Time
+}
+
+type PublishBackgroundResult struct {
+	RefID    string
+	Error    error
+	Duration time.Duration
+}
+
+type BlobRef struct {
+	Height     uint64
+	Commitment string
+}
+
+type BlobSubmitResult struct {
+	RefID string
+	Error error
+}
+
+type BlobChunk struct {
+	Offset int
+	Data   []byte
+	IsLast bool
 }
 
 func NewPublisher(config Config) (*Publisher, error) {
-	namespace, err := hex.DecodeString(config.NamespaceID)
+	namespace, err := parseNamespaceID(config.NamespaceID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid namespace ID: %w", err)
+		return nil, err
+	}
+
+	if !validVerificationMode(config.VerificationMode) {
+		return nil, fmt.Errorf("invalid verification mode %q", config.VerificationMode)
+	}
+	if !validCompression(config.Compression) {
+		return nil, fmt.Errorf("unsupported compression codec %q", config.Compression)
 	}
 
-	client, err := client.NewClient(context.Background(), config.Endpoint, config.AuthToken)
+	tokens := config.AuthTokens
+	if len(tokens) == 0 {
+		tokens = []string{config.AuthToken}
+	}
+
+	pool, err := newClientPool(config.Endpoint, tokens)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Celestia client: %w", err)
+		return nil, err
 	}
 
-	return &Publisher{
-		client:    client,
-		namespace: share.Namespace(namespace),
+	publisher := &Publisher{
+		client:    pool[0].client,
+		namespace: namespace,
 		config:    config,
-	}, nil
+		pool:      pool,
+	}
+	publisher.maxBlobSize.Store(config.MaxBlobSize)
+
+	if config.StrictVersionCheck {
+		version, compatible, err := publisher.CheckRPCVersion(context.Background())
+		if err != nil {
+			publisher.Close()
+			return nil, fmt.Errorf("failed to check node version: %w", err)
+		}
+		if !compatible {
+			publisher.Close()
+			return nil, fmt.Errorf("incompatible node version %s: need major version of %s", version, config.MinServerVersion)
+		}
+	}
+
+	return publisher, nil
+}
+
+// Namespace returns the namespace blobs are published to, hex encoded.
+func (p *Publisher) Namespace() string {
+	return hex.EncodeToString([]byte(p.currentNamespace()))
+}
+
+// NamespaceBytes returns a copy of the raw namespace.
+func (p *Publisher) NamespaceBytes() []byte {
+	return append([]byte(nil), p.currentNamespace()...)
+}
+
+func (p *Publisher) currentNamespace() share.Namespace {
+	p.namespaceMu.RLock()
+	defer p.namespaceMu.RUnlock()
+
+	return p.namespace
+}
+
+// setNamespace switches the namespace used by later submissions and reads.
+// Calls already in progress keep the namespace they started with.
+func (p *Publisher) setNamespace(namespaceID string) error {
+	namespace, err := parseNamespaceID(namespaceID)
+	if err != nil {
+		return err
+	}
+
+	p.namespaceMu.Lock()
+	defer p.namespaceMu.Unlock()
+
+	p.namespace = namespace
+	p.config.NamespaceID = namespaceID
+	return nil
+}
+
+// setMaxBlobSize changes the size limit checked before each submission.
+func (p *Publisher) setMaxBlobSize(maxBlobSize uint64) {
+	p.maxBlobSize.Store(maxBlobSize)
+}
+
+const redacted = "[redacted]"
+
+// Config returns a copy of the publisher's configuration that is safe to log:
+// AuthToken and every entry of AuthTokens are replaced with "[redacted]".
+func (p *Publisher) Config() Config {
+	p.namespaceMu.RLock()
+	config := p.config
+	p.namespaceMu.RUnlock()
+	config.MaxBlobSize = p.maxBlobSize.Load()
+
+	if config.AuthToken != "" {
+		config.AuthToken = redacted
+	}
+	if len(config.AuthTokens) > 0 {
+		config.AuthTokens = make([]string, len(p.config.AuthTokens))
+		for i := range config.AuthTokens {
+			config.AuthTokens[i] = redacted
+		}
+	}
+	return config
 }
 
 func (p *Publisher) PublishBatch(ctx context.Context, batchData []byte) (string, error) {
-	if uint64(len(batchData)) > p.config.MaxBlobSize {
-		return "", fmt.Errorf("batch data exceeds max blob size: %d > %d", len(batchData), p.config.MaxBlobSize)
+	return p.PublishBatchWithTimeout(ctx, batchData, 0)
+}
+
+// PublishBatchWithTimeout is PublishBatch with the submission deadline set to
+// timeout instead of Config.SubmitTimeout. A timeout <= 0 uses
+// Config.SubmitTimeout.
+func (p *Publisher) PublishBatchWithTimeout(ctx context.Context, batchData []byte, timeout time.Duration) (string, error) {
+	return p.publish(ctx, p.currentNamespace(), batchData, timeout, p.config.GasPrice)
+}
+
+// PublishBatchWithPriority is PublishBatch that, when highPriority is set,
+// pays Config.GasPrice * Config.PriorityGasMultiplier so the blob is more
+// likely to be included in the next block. The global gas price is not
+// changed.
+func (p *Publisher) PublishBatchWithPriority(ctx context.Context, batchData []byte, highPriority bool) (string, error) {
+	return p.publish(ctx, p.currentNamespace(), batchData, 0, p.gasPrice(highPriority))
+}
+
+// gasPrice returns the gas price for a submission, applying
+// Config.PriorityGasMultiplier (2 if unset) to high-priority ones.
+func (p *Publisher) gasPrice(highPriority bool) float64 {
+	return p.priorityGasPrice(p.config.GasPrice, highPriority)
+}
+
+func (p *Publisher) priorityGasPrice(base float64, highPriority bool) float64 {
+	if !highPriority {
+		return base
+	}
+
+	multiplier := p.config.PriorityGasMultiplier
+	if multiplier <= 0 {
+		multiplier = defaultPriorityGasMultiplier
+	}
+	return base * multiplier
+}
+
+// PublishBatchStream publishes batch data read from r. Celestia blobs are
+// submitted whole, so the data is still buffered in memory before submission,
+// but reading stops after maxSize bytes: a reader with more data fails with
+// ErrBlobTooLarge without reading the rest, which bounds memory use by
+// maxSize no matter how large the source is.
+func (p *Publisher) PublishBatchStream(ctx context.Context, r io.Reader, maxSize uint64) (string, error) {
+	limit := int64(math.MaxInt64)
+	if maxSize < math.MaxInt64 {
+		limit = int64(maxSize) + 1
+	}
+
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(r, limit))
+	if err != nil {
+		return "", fmt.Errorf("failed to read batch data: %w", err)
+	}
+	if uint64(n) > maxSize {
+		return "", fmt.Errorf("%w: batch data exceeds %d bytes", ErrBlobTooLarge, maxSize)
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, p.config.SubmitTimeout)
+	return p.PublishBatch(ctx, buf.Bytes())
+}
+
+func (p *Publisher) publish(ctx context.Context, namespace share.Namespace, batchData []byte, timeout time.Duration, gasPrice float64) (string, error) {
+	if err := p.checkBlobSize(batchData); err != nil {
+		return "", err
+	}
+
+	if timeout <= 0 {
+		timeout = p.config.SubmitTimeout
+	}
+	submitCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	blob, err := blob.NewBlob(p.namespace, batchData, share.DefaultShareVersion)
+	b, err := blob.NewBlob(namespace, batchData, share.DefaultShareVersion)
 	if err != nil {
 		return "", fmt.Errorf("failed to create blob: %w", err)
 	}
 
-	height, err := p.client.Blob.Submit(ctx, []*blob.Blob{blob}, &blob.SubmitOptions{
-		GasPrice: p.config.GasPrice,
+	pc := p.nextClient()
+	rpcStart := time.Now()
+	height, err := pc.client.Blob.Submit(submitCtx, []*blob.Blob{b}, &blob.SubmitOptions{
+		GasPrice: gasPrice,
 	})
+	p.traceRPC("Blob.Submit", rpcStart, err)
 	if err != nil {
+		if isRateLimited(err) {
+			pc.coolDown(p.tokenCooldown())
+		}
 		return "", fmt.Errorf("failed to submit blob: %w", err)
 	}
 
-	commitment, err := blob.CreateCommitment(blob)
+	commitment, err := blob.CreateCommitment(b)
 	if err != nil {
 		return "", fmt.Errorf("failed to create commitment: %w", err)
 	}
+	p.recordGas(batchData, gasPrice)
 
-	return fmt.Sprintf("%d:%s", height, hex.EncodeToString(commitment)), nil
+	refID := fmt.Sprintf("%d:%s", height, hex.EncodeToString(commitment))
+	if err := p.awaitSampling(ctx, height); err != nil {
+		return "", unconfirmedError{
+			refID: refID,
+			err:   fmt.Errorf("blob submitted at height %d but not verified: %w", height, err),
+		}
+	}
+
+	return refID, nil
+}
+
+func (p *Publisher) checkBlobSize(data []byte) error {
+	_, shares, err := BatchDataSize(data, share.DefaultShareVersion)
+	if err != nil {
+		return fmt.Errorf("invalid batch data: %w", err)
+	}
+	maxBlobSize := p.maxBlobSize.Load()
+	if paddedSize := uint64(shares) * shareSize; paddedSize > maxBlobSize {
+		return fmt.Errorf("%w: batch data exceeds max blob size: %d bytes (%d shares, %d padded) > %d",
+			ErrBlobTooLarge, len(data), shares, paddedSize, maxBlobSize)
+	}
+	return nil
+}
+
+// SubmitBlobs submits independent payloads in a single Blob.Submit call, so
+// they land at the same height but each gets its own ref ID. Payloads that
+// cannot be turned into blobs fail individually; if the submission itself
+// fails, every remaining result carries that error. If the blobs were
+// included but sampling them failed, each result carries both its ref ID and
+// the error.
+func (p *Publisher) SubmitBlobs(ctx context.Context, payloads [][]byte) []BlobSubmitResult {
+	return p.submitBlobs(ctx, payloads, p.config.GasPrice)
+}
+
+func (p *Publisher) submitBlobs(ctx context.Context, payloads [][]byte, gasPrice float64) []BlobSubmitResult {
+	results := make([]BlobSubmitResult, len(payloads))
+	namespace := p.currentNamespace()
+
+	var blobs []*blob.Blob
+	var indexes []int
+	for i, payload := range payloads {
+		if err := p.checkBlobSize(payload); err != nil {
+			results[i].Error = err
+			continue
+		}
+
+		b, err := blob.NewBlob(namespace, payload, share.DefaultShareVersion)
+		if err != nil {
+			results[i].Error = fmt.Errorf("failed to create blob: %w", err)
+			continue
+		}
+		blobs = append(blobs, b)
+		indexes = append(indexes, i)
+	}
+
+	if len(blobs) == 0 {
+		return results
+	}
+
+	submitCtx, cancel := context.WithTimeout(ctx, p.config.SubmitTimeout)
+	defer cancel()
+
+	pc := p.nextClient()
+	rpcStart := time.Now()
+	height, err := pc.client.Blob.Submit(submitCtx, blobs, &blob.SubmitOptions{
+		GasPrice: gasPrice,
+	})
+	p.traceRPC("Blob.Submit", rpcStart, err)
+	if err != nil {
+		if isRateLimited(err) {
+			pc.coolDown(p.tokenCooldown())
+		}
+		for _, i := range indexes {
+			results[i].Error = fmt.Errorf("failed to submit blobs: %w", err)
+		}
+		return results
+	}
+
+	for _, i := range indexes {
+		p.recordGas(payloads[i], gasPrice)
+	}
+
+	for j, b := range blobs {
+		i := indexes[j]
+		commitment, err := blob.CreateCommitment(b)
+		if err != nil {
+			results[i].Error = fmt.Errorf("failed to create commitment: %w", err)
+			continue
+		}
+		results[i].RefID = fmt.Sprintf("%d:%s", height, hex.EncodeToString(commitment))
+	}
+
+	if err := p.awaitSampling(ctx, height); err != nil {
+		for _, i := range indexes {
+			if results[i].Error != nil {
+				continue
+			}
+			results[i].Error = unconfirmedError{
+				refID: results[i].RefID,
+				err:   fmt.Errorf("blobs submitted at height %d but not verified: %w", height, err),
+			}
+		}
+	}
+
+	return results
+}
+
+// SubmitAndPoll publishes data and then polls the network head until the
+// submission height is buried under the requested number of confirmations.
+// It returns the ref ID and the network head height that satisfied it.
+func (p *Publisher) SubmitAndPoll(ctx context.Context, data []byte, confirmations uint64) (string, uint64, error) {
+	return p.submitAndPoll(ctx, data, confirmations, 0, p.config.GasPrice)
+}
+
+func (p *Publisher) submitAndPoll(ctx context.Context, data []byte, confirmations uint64, timeout time.Duration, gasPrice float64) (string, uint64, error) {
+	refID, err := p.publish(ctx, p.currentNamespace(), data, timeout, gasPrice)
+	if err != nil {
+		return "", 0, err
+	}
+
+	head, err := p.awaitConfirmations(ctx, refID, confirmations)
+	if err != nil {
+		return refID, 0, unconfirmedError{refID: refID, sampled: true, err: err}
+	}
+	return refID, head, nil
+}
+
+// unconfirmedError reports a blob that was submitted, and paid for, but
+// whose verification failed afterwards: the DAS sampling wait unless sampled
+// is set, otherwise the confirmation poll. Retries must repeat only those
+// steps with confirm, never the submission.
+type unconfirmedError struct {
+	refID   string
+	sampled bool
+	err     error
+}
+
+func (e unconfirmedError) Error() string {
+	return e.err.Error()
+}
+
+func (e unconfirmedError) Unwrap() error {
+	return e.err
+}
+
+// confirm resumes the verification of a blob after an unconfirmedError,
+// from the step that failed. A failure is again reported as an
+// unconfirmedError.
+func (p *Publisher) confirm(ctx context.Context, pending unconfirmedError, confirmations uint64) error {
+	if !pending.sampled {
+		height, _, err := parseRefID(pending.refID)
+		if err != nil {
+			return err
+		}
+		if err := p.awaitSampling(ctx, height); err != nil {
+			return unconfirmedError{
+				refID: pending.refID,
+				err:   fmt.Errorf("blob submitted at height %d but not verified: %w", height, err),
+			}
+		}
+	}
+
+	if confirmations == 0 {
+		return nil
+	}
+	if _, err := p.awaitConfirmations(ctx, pending.refID, confirmations); err != nil {
+		return unconfirmedError{refID: pending.refID, sampled: true, err: err}
+	}
+	return nil
+}
+
+// awaitConfirmations polls the network head until the height of refID is
+// buried under confirmations blocks, and returns the head that satisfied it.
+func (p *Publisher) awaitConfirmations(ctx context.Context, refID string, confirmations uint64) (uint64, error) {
+	height, _, err := parseRefID(refID)
+	if err != nil {
+		return 0, err
+	}
+	target := height + confirmations
+
+	ticker := time.NewTicker(finalityPollInterval)
+	defer ticker.Stop()
+
+	for {
+		rpcStart := time.Now()
+		head, err := p.client.Header.NetworkHead(ctx)
+		p.traceRPC("Header.NetworkHead", rpcStart, err)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get network head: %w", err)
+		}
+		p.recordTipHeight(head.Height())
+		if head.Height() >= target {
+			return head.Height(), nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return 0, fmt.Errorf("waiting for %d confirmations of height %d: %w", confirmations, height, ctx.Err())
+		}
+	}
+}
+
+// SubmitInBackground publishes data in a new goroutine and returns at once.
+// The result is delivered on the returned channel, which is buffered so
+// callers that never read it do not leak the goroutine.
+func (p *Publisher) SubmitInBackground(ctx context.Context, data []byte) <-chan PublishBackgroundResult {
+	resultChan := make(chan PublishBackgroundResult, 1)
+
+	go func() {
+		start := time.Now()
+		refID, err := p.PublishBatch(ctx, data)
+		resultChan <- PublishBackgroundResult{
+			RefID:    refID,
+			Error:    err,
+			Duration: time.Since(start),
+		}
+	}()
+
+	return resultChan
 }
 
 func (p *Publisher) RetrieveBatch(ctx context.Context, height uint64, commitment string) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(ctx, p.config.SubmitTimeout)
+	return p.retrieve(ctx, p.currentNamespace(), height, commitment, 0)
+}
+
+// RetrieveBatchWithTimeout is RetrieveBatch with the deadline set to timeout
+// instead of Config.SubmitTimeout. A timeout <= 0 uses Config.SubmitTimeout.
+func (p *Publisher) RetrieveBatchWithTimeout(ctx context.Context, height uint64, commitment string, timeout time.Duration) ([]byte, error) {
+	return p.retrieve(ctx, p.currentNamespace(), height, commitment, timeout)
+}
+
+// RetrieveBatchFast is RetrieveBatch bounded by Config.RetrieveTimeout, which
+// is usually much shorter than the submit timeout. It uses
+// Config.SubmitTimeout if RetrieveTimeout is not set.
+func (p *Publisher) RetrieveBatchFast(ctx context.Context, height uint64, commitment string) ([]byte, error) {
+	return p.retrieve(ctx, p.currentNamespace(), height, commitment, p.config.RetrieveTimeout)
+}
+
+func (p *Publisher) retrieve(ctx context.Context, namespace share.Namespace, height uint64, commitment string, timeout time.Duration) ([]byte, error) {
+	b, err := p.getBlob(ctx, namespace, height, commitment, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return b.Data, nil
+}
+
+// GetBlobVersion returns the share version a stored blob was encoded with.
+func (p *Publisher) GetBlobVersion(ctx context.Context, height uint64, commitment string) (uint8, error) {
+	b, err := p.getBlob(ctx, p.currentNamespace(), height, commitment, 0)
+	if err != nil {
+		return 0, err
+	}
+	return uint8(b.ShareVersion), nil
+}
+
+func (p *Publisher) getBlob(ctx context.Context, namespace share.Namespace, height uint64, commitment string, timeout time.Duration) (*blob.Blob, error) {
+	if timeout <= 0 {
+		timeout = p.config.SubmitTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	commitmentBytes, err := hex.DecodeString(commitment)
@@ -81,17 +584,112 @@ func (p *Publisher) RetrieveBatch(ctx context.Context, height uint64, commitment
 		return nil, fmt.Errorf("invalid commitment: %w", err)
 	}
 
-	blob, err := p.client.Blob.Get(ctx, height, p.namespace, commitmentBytes)
+	rpcStart := time.Now()
+	b, err := p.client.Blob.Get(ctx, height, namespace, commitmentBytes)
+	p.traceRPC("Blob.Get", rpcStart, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get blob: %w", err)
 	}
 
-	return blob.Data, nil
+	return b, nil
+}
+
+// GetBlobSize returns the byte length of a blob. The Celestia RPC has no
+// metadata-only blob query, so the blob is downloaded and discarded.
+func (p *Publisher) GetBlobSize(ctx context.Context, height uint64, commitment string) (uint64, error) {
+	data, err := p.RetrieveBatch(ctx, height, commitment)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(len(data)), nil
+}
+
+func (p *Publisher) GetBlobsByCommitments(ctx context.Context, refs []BlobRef) ([][]byte, error) {
+	results := make([][]byte, len(refs))
+	for i, ref := range refs {
+		data, err := p.RetrieveBatch(ctx, ref.Height, ref.Commitment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get blob %d:%s: %w", ref.Height, ref.Commitment, err)
+		}
+		results[i] = data
+	}
+	return results, nil
+}
+
+// GetBlobs fetches blobs by ref ID. Every ref ID is parsed before any RPC is
+// made, so a malformed one fails the call without side effects.
+func (p *Publisher) GetBlobs(ctx context.Context, refIDs ...string) ([][]byte, error) {
+	refs := make([]BlobRef, len(refIDs))
+	for i, refID := range refIDs {
+		height, commitment, err := parseRefID(refID)
+		if err != nil {
+			return nil, err
+		}
+		refs[i] = BlobRef{Height: height, Commitment: commitment}
+	}
+
+	return p.GetBlobsByCommitments(ctx, refs)
+}
+
+// PaginatedGet fetches a blob and streams it as chunks of at most chunkSize
+// bytes so consumers can pipe it downstream without holding a second copy.
+// Blobs are atomic on Celestia, so the fetch itself happens once, up front.
+func (p *Publisher) PaginatedGet(ctx context.Context, height uint64, commitment string, chunkSize int) (<-chan BlobChunk, error) {
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("invalid chunk size: %d", chunkSize)
+	}
+
+	data, err := p.RetrieveBatch(ctx, height, commitment)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan BlobChunk)
+
+	go func() {
+		defer close(chunks)
+
+		for offset := 0; offset == 0 || offset < len(data); offset += chunkSize {
+			end := offset + chunkSize
+			if end > len(data) {
+				end = len(data)
+			}
+
+			select {
+			case chunks <- BlobChunk{
+				Offset: offset,
+				Data:   data[offset:end],
+				IsLast: end == len(data),
+			}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return chunks, nil
+}
+
+func parseRefID(refID string) (uint64, string, error) {
+	heightStr, commitment, ok := strings.Cut(refID, ":")
+	if !ok || commitment == "" {
+		return 0, "", fmt.Errorf("invalid ref ID %q: expected height:commitment", refID)
+	}
+
+	height, err := strconv.ParseUint(heightStr, 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid ref ID %q: %w", refID, err)
+	}
+
+	return height, commitment, nil
 }
 
 func (p *Publisher) Close() error {
-	if p.client != nil {
-		return p.client.Close()
+	var firstErr error
+	for _, pc := range p.pool {
+		if err := pc.client.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
 	}
-	return nil
+	return firstErr
 }
\ No newline at end of file
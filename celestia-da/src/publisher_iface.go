@@ -0,0 +1,61 @@
+package celestiada
+
+import (
+	"context"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+// PublisherIface is the blob submission and retrieval surface of Publisher.
+// Code that only moves batch data can depend on it instead of a live node.
+type PublisherIface interface {
+	PublishBatch(ctx context.Context, batchData []byte) (string, error)
+	SubmitBlobs(ctx context.Context, payloads [][]byte) []BlobSubmitResult
+	SubmitAndPoll(ctx context.Context, data []byte, confirmations uint64) (string, uint64, error)
+	SubmitInBackground(ctx context.Context, data []byte) <-chan PublishBackgroundResult
+	RetrieveBatch(ctx context.Context, height uint64, commitment string) ([]byte, error)
+	GetBlobSize(ctx context.Context, height uint64, commitment string) (uint64, error)
+	GetBlobsByCommitments(ctx context.Context, refs []BlobRef) ([][]byte, error)
+	GetBlobs(ctx context.Context, refIDs ...string) ([][]byte, error)
+	PaginatedGet(ctx context.Context, height uint64, commitment string, chunkSize int) (<-chan BlobChunk, error)
+	Close() error
+}
+
+// batchPublisher is everything CDKIntegration needs from its publisher:
+// PublisherIface plus the namespace, fee and fallback hooks the batch
+// pipeline uses internally. Publisher implements it; tests in this package
+// substitute fakes.
+type batchPublisher interface {
+	PublisherIface
+
+	Namespace() string
+	GetNamespaceBlobs(ctx context.Context, fromHeight, toHeight uint64) (<-chan NamespaceBlobResult, error)
+	SubscribeNamespace(ctx context.Context) (<-chan BlobEvent, error)
+	Ping(ctx context.Context) error
+	RetrieveFallback(ctx context.Context, refID string) ([]byte, error)
+
+	currentNamespace() share.Namespace
+	setNamespace(namespaceID string) error
+	setMaxBlobSize(maxBlobSize uint64)
+	gasPrice(highPriority bool) float64
+	feeGasPrice(ctx context.Context, highPriority bool) (float64, error)
+	checkFeeLimit(data []byte, maxFeeUTIA uint64, gasPrice float64) error
+	publish(ctx context.Context, namespace share.Namespace, batchData []byte, timeout time.Duration, gasPrice float64) (string, error)
+	submitBlobs(ctx context.Context, payloads [][]byte, gasPrice float64) []BlobSubmitResult
+	submitAndPoll(ctx context.Context, data []byte, confirmations uint64, timeout time.Duration, gasPrice float64) (string, uint64, error)
+	confirm(ctx context.Context, pending unconfirmedError, confirmations uint64) error
+	getBlob(ctx context.Context, namespace share.Namespace, height uint64, commitment string, timeout time.Duration) (*blob.Blob, error)
+	retrieve(ctx context.Context, namespace share.Namespace, height uint64, commitment string, timeout time.Duration) ([]byte, error)
+	namespaceTreeProof(ctx context.Context, namespace share.Namespace, height uint64, commitment string) (*NMTProof, error)
+	fallbackDA() FallbackDA
+	submitFallback(ctx context.Context, data []byte) (string, error)
+	networkHead(ctx context.Context) (uint64, error)
+	blockTime(ctx context.Context, height uint64) (time.Time, error)
+}
+
+var (
+	_ PublisherIface = (*Publisher)(nil)
+	_ batchPublisher = (*Publisher)(nil)
+)
@@ -0,0 +1,250 @@
+package celestiada
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	client "github.com/celestiaorg/celestia-openrpc/types/client"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+// newTestPublisher returns a Publisher in fakeNamespace that talks to rpc
+// instead of a node.
+func newTestPublisher(rpc *client.Client) *Publisher {
+	return &Publisher{
+		client:    rpc,
+		namespace: fakeNamespace,
+		config:    Config{SubmitTimeout: time.Second},
+		pool:      []*pooledClient{{client: rpc}},
+	}
+}
+
+func TestGetBlobSizeMatchesDataLength(t *testing.T) {
+	stored := map[string][]byte{
+		"01": []byte("x"),
+		"02": bytes.Repeat([]byte("batch"), 1000),
+		"03": {},
+	}
+
+	rpc := &client.Client{}
+	rpc.Blob.Internal.Get = func(ctx context.Context, height uint64, ns share.Namespace, c blob.Commitment) (*blob.Blob, error) {
+		data, ok := stored[hex.EncodeToString(c)]
+		if !ok || height != 7 {
+			return nil, fmt.Errorf("blob: not found")
+		}
+		return &blob.Blob{Namespace: ns, Data: data}, nil
+	}
+	p := newTestPublisher(rpc)
+
+	for commitment, data := range stored {
+		size, err := p.GetBlobSize(context.Background(), 7, commitment)
+		if err != nil {
+			t.Fatalf("GetBlobSize(%s): %v", commitment, err)
+		}
+		if size != uint64(len(data)) {
+			t.Fatalf("GetBlobSize(%s) = %d, want %d", commitment, size, len(data))
+		}
+	}
+
+	if _, err := p.GetBlobSize(context.Background(), 8, "01"); err == nil {
+		t.Fatal("GetBlobSize succeeded for a missing blob")
+	}
+}
+
+func TestNamespaceUsageOverRangeBounds(t *testing.T) {
+	rpc := &client.Client{}
+	rpc.Blob.Internal.GetAll = func(ctx context.Context, height uint64, namespaces []share.Namespace) ([]*blob.Blob, error) {
+		return nil, nil
+	}
+	p := newTestPublisher(rpc)
+
+	usage, err := p.NamespaceUsageOverRange(context.Background(), math.MaxUint64-1, math.MaxUint64)
+	if err != nil {
+		t.Fatalf("NamespaceUsageOverRange: %v", err)
+	}
+	if len(usage) != 2 || usage[1].Height != math.MaxUint64 {
+		t.Fatalf("usage = %+v, want heights %d and %d", usage, uint64(math.MaxUint64-1), uint64(math.MaxUint64))
+	}
+
+	if _, err := p.NamespaceUsageOverRange(context.Background(), 1, math.MaxUint64); err == nil {
+		t.Fatal("NamespaceUsageOverRange accepted an unbounded range")
+	}
+	if _, err := p.NamespaceUsageOverRange(context.Background(), 5, 4); err == nil {
+		t.Fatal("NamespaceUsageOverRange accepted from > to")
+	}
+}
+
+func TestSplitBlobShares(t *testing.T) {
+	data := bytes.Repeat([]byte{0xab}, firstSparseShareContentSize+continuationSparseShareContentSize+1)
+
+	shares, err := splitBlobShares(fakeNamespace, data, share.DefaultShareVersion)
+	if err != nil {
+		t.Fatalf("splitBlobShares: %v", err)
+	}
+	if len(shares) != 3 {
+		t.Fatalf("got %d shares, want 3", len(shares))
+	}
+
+	var content []byte
+	for i, sh := range shares {
+		if len(sh) != shareSize {
+			t.Fatalf("share %d is %d bytes, want %d", i, len(sh), shareSize)
+		}
+		if !bytes.Equal(sh[:namespaceSize], fakeNamespace) {
+			t.Fatalf("share %d has namespace %x", i, sh[:namespaceSize])
+		}
+
+		info := sh[namespaceSize]
+		body := sh[namespaceSize+shareInfoBytes:]
+		if i == 0 {
+			if info != 1 {
+				t.Fatalf("first share info byte = %#x, want 0x1", info)
+			}
+			if got := binary.BigEndian.Uint32(body); got != uint32(len(data)) {
+				t.Fatalf("sequence length = %d, want %d", got, len(data))
+			}
+			body = body[sequenceLenBytes:]
+		} else if info != 0 {
+			t.Fatalf("share %d info byte = %#x, want 0x0", i, info)
+		}
+		content = append(content, body...)
+	}
+
+	if !bytes.Equal(content[:len(data)], data) {
+		t.Fatal("shares do not hold the blob data in order")
+	}
+	if bytes.Count(content[len(data):], []byte{0}) != len(content)-len(data) {
+		t.Fatal("last share is not zero padded")
+	}
+}
+
+func TestStartHealthMonitorRejectsNonPositiveInterval(t *testing.T) {
+	p := newTestPublisher(&client.Client{})
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if err := p.StartHealthMonitor(context.Background(), interval, nil); err == nil {
+			t.Fatalf("StartHealthMonitor accepted interval %v", interval)
+		}
+	}
+}
+
+func TestNamespaceRootCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	var cache nsRootCache
+	for height := uint64(1); height <= maxNamespaceRootEntries; height++ {
+		cache.store(nsRootKey{height: height}, []byte{byte(height)})
+	}
+	if _, ok := cache.load(nsRootKey{height: 1}); !ok {
+		t.Fatal("height 1 missing before the cache was full")
+	}
+
+	cache.store(nsRootKey{height: maxNamespaceRootEntries + 1}, []byte{0})
+	if _, ok := cache.load(nsRootKey{height: 1}); !ok {
+		t.Fatal("recently used height 1 was evicted")
+	}
+	if _, ok := cache.load(nsRootKey{height: 2}); ok {
+		t.Fatal("least recently used height 2 was not evicted")
+	}
+	if got := len(cache.entries); got != maxNamespaceRootEntries {
+		t.Fatalf("cache holds %d entries, want %d", got, maxNamespaceRootEntries)
+	}
+}
+
+func TestRetrieveLargeBlobInRangeSkipsForgedShards(t *testing.T) {
+	data := bytes.Repeat([]byte("large blob "), 20)
+	lb, err := NewLargeBlob(fakeNamespace, data, largeBlobHeaderSize+64)
+	if err != nil {
+		t.Fatalf("NewLargeBlob: %v", err)
+	}
+
+	// A forged shard claims the maximum shard count and another claims the
+	// real count with a different payload; both precede the real shards.
+	huge := append([]byte(nil), lb.Shards[0]...)
+	binary.BigEndian.PutUint32(huge[4:8], math.MaxUint32)
+	mismatched := append([]byte(nil), lb.Shards[1]...)
+	binary.BigEndian.PutUint32(mismatched[4:8], uint32(len(lb.Shards)+1))
+	mismatched[0], mismatched[1], mismatched[2], mismatched[3] = 0, 0, 0, byte(len(lb.Shards))
+
+	blobsAt := map[uint64][][]byte{
+		1: {huge, mismatched},
+		2: lb.Shards,
+	}
+	rpc := &client.Client{}
+	rpc.Blob.Internal.GetAll = func(ctx context.Context, height uint64, namespaces []share.Namespace) ([]*blob.Blob, error) {
+		var blobs []*blob.Blob
+		for _, shard := range blobsAt[height] {
+			blobs = append(blobs, &blob.Blob{Namespace: fakeNamespace, Data: shard})
+		}
+		return blobs, nil
+	}
+	p := newTestPublisher(rpc)
+
+	got, err := p.RetrieveLargeBlobInRange(context.Background(), hex.EncodeToString(lb.ParentCommitment), 1, 2)
+	if err != nil {
+		t.Fatalf("RetrieveLargeBlobInRange: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("reassembled data does not match the original")
+	}
+}
+
+func TestDryRunEstimateReportsCompressionRatio(t *testing.T) {
+	p := newTestPublisher(&client.Client{})
+	p.maxBlobSize.Store(1 << 20)
+	data := bytes.Repeat([]byte("compressible batch data "), 1000)
+
+	plain, err := p.DryRunEstimate(data)
+	if err != nil {
+		t.Fatalf("DryRunEstimate: %v", err)
+	}
+	if plain.CompressionRatio != 1 {
+		t.Fatalf("ratio without compression = %v, want 1", plain.CompressionRatio)
+	}
+
+	p.config.Compression = CompressionZlib
+	compressed, err := p.DryRunEstimate(data)
+	if err != nil {
+		t.Fatalf("DryRunEstimate: %v", err)
+	}
+	if compressed.CompressionRatio <= 1 || compressed.PaddedShareCount >= plain.PaddedShareCount {
+		t.Fatalf("compressed estimate = %+v, want a ratio above 1 and fewer shares than %d", compressed, plain.PaddedShareCount)
+	}
+}
+
+func TestPublishBatchWithFeeLimitUsesEstimatedGasPrice(t *testing.T) {
+	rpc := &client.Client{}
+	rpc.State.Internal.EstimateGasPrice = func(context.Context) (float64, error) {
+		return 5, nil
+	}
+	var submittedAt []float64
+	rpc.Blob.Internal.Submit = func(ctx context.Context, blobs []*blob.Blob, opts *blob.SubmitOptions) (uint64, error) {
+		submittedAt = append(submittedAt, opts.GasPrice)
+		return 0, fmt.Errorf("not accepted")
+	}
+	p := newTestPublisher(rpc)
+	p.maxBlobSize.Store(1 << 20)
+
+	data := []byte("batch")
+	fee := uint64(estimateDataGas(data)) * 5
+
+	// Without Config.GasPrice the cap is checked at the node's estimate.
+	if _, err := p.PublishBatchWithFeeLimit(context.Background(), data, fee-1); !errors.Is(err, ErrFeeLimitExceeded) {
+		t.Fatalf("batch over its fee limit: error = %v, want %v", err, ErrFeeLimitExceeded)
+	}
+	if len(submittedAt) != 0 {
+		t.Fatalf("batch over its fee limit was submitted")
+	}
+
+	// A batch within the cap is submitted at the price it was checked at.
+	p.PublishBatchWithFeeLimit(context.Background(), data, fee)
+	if len(submittedAt) != 1 || submittedAt[0] != 5 {
+		t.Fatalf("submitted at gas prices %v, want [5]", submittedAt)
+	}
+}
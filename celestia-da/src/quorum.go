@@ -0,0 +1,132 @@
+package celestiada
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ErrQuorumFailed is returned when too many quorum publishers failed for the
+// quorum to be reached. Errors holds each failed publisher's error, indexed
+// like the publishers passed to NewQuorumCDKIntegration; entries for
+// publishers that succeeded are nil.
+type ErrQuorumFailed struct {
+	Quorum    int
+	Successes int
+	Errors    []error
+}
+
+func (e ErrQuorumFailed) Error() string {
+	var failures []string
+	for i, err := range e.Errors {
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("publisher %d: %v", i, err))
+		}
+	}
+	return fmt.Sprintf("quorum of %d not reached (%d succeeded): %s",
+		e.Quorum, e.Successes, strings.Join(failures, "; "))
+}
+
+type quorumPublisher struct {
+	publishers []PublisherIface
+	quorum     int
+}
+
+// NewQuorumCDKIntegration creates an integration that writes every batch to
+// all of publishers concurrently and treats it as published once quorum of
+// them have confirmed it. Reads, namespace management and the rest of the
+// integration still go through the publisher built from config. Quorum
+// publishers submit at their own gas price, so BatchData.UseHighPriority has
+// no effect. The caller keeps ownership of publishers and must close them
+// after the integration.
+func NewQuorumCDKIntegration(config Config, publishers []PublisherIface, quorum int) (*CDKIntegration, error) {
+	if quorum < 1 || quorum > len(publishers) {
+		return nil, fmt.Errorf("invalid quorum %d for %d publishers", quorum, len(publishers))
+	}
+
+	c, err := NewCDKIntegration(config)
+	if err != nil {
+		return nil, err
+	}
+
+	c.quorum = &quorumPublisher{
+		publishers: append([]PublisherIface(nil), publishers...),
+		quorum:     quorum,
+	}
+	return c, nil
+}
+
+type quorumResult struct {
+	index int
+	refID string
+	err   error
+}
+
+// quorumAttempt carries what earlier quorum attempts for one batch achieved,
+// so that a retry only goes to the publishers that have not published it.
+type quorumAttempt struct {
+	refIDs []string
+	first  string
+}
+
+// publish submits data to every publisher that has not already published it
+// in attempt and returns the ref ID of the first confirmation once quorum is
+// reached. Otherwise it waits for the remaining submissions, records the
+// ones that succeeded in attempt, and returns ErrQuorumFailed.
+func (q *quorumPublisher) publish(ctx context.Context, data []byte, confirmations uint64, attempt *quorumAttempt) (string, error) {
+	if attempt.refIDs == nil {
+		attempt.refIDs = make([]string, len(q.publishers))
+	}
+
+	results := make(chan quorumResult, len(q.publishers))
+	successes, running := 0, 0
+	for i, p := range q.publishers {
+		if attempt.refIDs[i] != "" {
+			successes++
+			continue
+		}
+		running++
+		go func(i int, p PublisherIface) {
+			var refID string
+			var err error
+			if confirmations > 0 {
+				refID, _, err = p.SubmitAndPoll(ctx, data, confirmations)
+			} else {
+				refID, err = p.PublishBatch(ctx, data)
+			}
+			results <- quorumResult{index: i, refID: refID, err: err}
+		}(i, p)
+	}
+
+	errs := make([]error, len(q.publishers))
+	for ; running > 0; running-- {
+		result := <-results
+		if result.err != nil {
+			errs[result.index] = result.err
+			continue
+		}
+
+		attempt.refIDs[result.index] = result.refID
+		if attempt.first == "" {
+			attempt.first = result.refID
+		}
+		successes++
+		if successes >= q.quorum {
+			return attempt.first, nil
+		}
+	}
+	if successes >= q.quorum {
+		return attempt.first, nil
+	}
+
+	return "", ErrQuorumFailed{Quorum: q.quorum, Successes: successes, Errors: errs}
+}
+
+func (c *CDKIntegration) publishQuorum(ctx context.Context, batch *BatchData, attempt *quorumAttempt) (string, error) {
+	if batch.TimeoutOverride > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, batch.TimeoutOverride)
+		defer cancel()
+	}
+	return c.quorum.publish(ctx, batch.Data, c.config.DefaultConfirmations, attempt)
+}
@@ -0,0 +1,45 @@
+package celestiada
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyPublisher fails the first failures calls to PublishBatch.
+type flakyPublisher struct {
+	PublisherIface
+	failures atomic.Int32
+}
+
+func (f *flakyPublisher) PublishBatch(ctx context.Context, batchData []byte) (string, error) {
+	if f.failures.Add(-1) >= 0 {
+		return "", errors.New("node unavailable")
+	}
+	return f.PublisherIface.PublishBatch(ctx, batchData)
+}
+
+func TestQuorumRetriesOnlyMissingPublishers(t *testing.T) {
+	steady, flaky := NewFakePublisher(), NewFakePublisher()
+	c := newTestIntegration(t, Config{MaxRetries: 2, RetryDelay: time.Millisecond}, NewFakePublisher())
+
+	unreliable := &flakyPublisher{PublisherIface: flaky}
+	unreliable.failures.Store(1)
+	c.quorum = &quorumPublisher{
+		publishers: []PublisherIface{steady, unreliable},
+		quorum:     2,
+	}
+
+	result := <-c.SubmitBatch(1, []byte("batch"), "root", 1)
+	if !result.Success {
+		t.Fatalf("batch failed: %v", result.Error)
+	}
+	if got := len(steady.PublishedBlobs); got != 1 {
+		t.Fatalf("steady publisher published %d blobs, want 1", got)
+	}
+	if got := len(flaky.PublishedBlobs); got != 1 {
+		t.Fatalf("flaky publisher published %d blobs, want 1", got)
+	}
+}
@@ -0,0 +1,140 @@
+package celestiada
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ReconcileBlob is a batch blob found on Celestia. BatchNumber is only known
+// when a batch deserializer is registered and decodes the blob; it is zero
+// otherwise.
+type ReconcileBlob struct {
+	BatchNumber uint64
+	Height      uint64
+	Commitment  string
+	RefID       string
+}
+
+// ReconcileMismatch is a batch whose stored metadata points at a different
+// blob than the one found on Celestia for the same batch number.
+type ReconcileMismatch struct {
+	Local   *BatchMetadata
+	OnChain ReconcileBlob
+}
+
+// ReconcileReport is the result of ReconcileWithCelestia. Missing lists blobs
+// on Celestia with no local metadata, Extra lists local metadata whose blob
+// is not on Celestia in the range, and Mismatched lists batches recorded
+// against a different blob than the one found.
+type ReconcileReport struct {
+	FromHeight uint64
+	ToHeight   uint64
+	Missing    []ReconcileBlob
+	Extra      []*BatchMetadata
+	Mismatched []ReconcileMismatch
+}
+
+// InSync reports whether the reconciliation found no differences.
+func (r *ReconcileReport) InSync() bool {
+	return len(r.Missing) == 0 && len(r.Extra) == 0 && len(r.Mismatched) == 0
+}
+
+// ReconcileWithCelestia compares the metadata stored for heights [fromHeight,
+// toHeight] with the blobs actually in the current namespace at those
+// heights. It only reports differences and never modifies the store;
+// MetadataRepair can be used to act on Missing entries. Batches on the
+// fallback DA layer and in other namespaces are ignored. Detecting
+// mismatched batches needs a registered batch deserializer; without one, a
+// mismatch shows up as one Missing and one Extra entry.
+func (c *CDKIntegration) ReconcileWithCelestia(ctx context.Context, fromHeight, toHeight uint64) (*ReconcileReport, error) {
+	if fromHeight == 0 || fromHeight > toHeight {
+		return nil, fmt.Errorf("invalid height range: %d-%d", fromHeight, toHeight)
+	}
+
+	c.deserializerMu.RLock()
+	deserialize := c.deserializer
+	c.deserializerMu.RUnlock()
+
+	namespace := c.publisher.Namespace()
+	local := make(map[string]*BatchMetadata)
+	byNumber := make(map[uint64]*BatchMetadata)
+
+	err := c.metadataStore.Range(func(metadata *BatchMetadata) bool {
+		byNumber[metadata.BatchNumber] = metadata
+		if metadata.DALayer == DALayerFallback {
+			return true
+		}
+		if metadata.Namespace != "" && metadata.Namespace != namespace {
+			return true
+		}
+		if metadata.CelestiaHeight >= fromHeight && metadata.CelestiaHeight <= toHeight {
+			local[strings.ToLower(metadata.Commitment)] = metadata
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata store: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results, err := c.publisher.GetNamespaceBlobs(ctx, fromHeight, toHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReconcileReport{FromHeight: fromHeight, ToHeight: toHeight}
+	found := make(map[string]bool)
+	mismatched := make(map[uint64]bool)
+
+	for result := range results {
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		commitment := strings.ToLower(result.Commitment)
+		found[commitment] = true
+		if _, ok := local[commitment]; ok {
+			continue
+		}
+
+		blob := ReconcileBlob{
+			Height:     result.Height,
+			Commitment: result.Commitment,
+			RefID:      fmt.Sprintf("%d:%s", result.Height, result.Commitment),
+		}
+		if deserialize != nil {
+			data, _ := c.decodeBlobData(result.Data)
+			batch, err := deserialize(data)
+			if err != nil || batch == nil {
+				continue
+			}
+			blob.BatchNumber = batch.Number
+
+			if metadata, ok := byNumber[batch.Number]; ok {
+				report.Mismatched = append(report.Mismatched, ReconcileMismatch{Local: metadata, OnChain: blob})
+				mismatched[batch.Number] = true
+				continue
+			}
+		}
+		report.Missing = append(report.Missing, blob)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for commitment, metadata := range local {
+		if !found[commitment] && !mismatched[metadata.BatchNumber] {
+			report.Extra = append(report.Extra, metadata)
+		}
+	}
+
+	sort.Slice(report.Extra, func(i, j int) bool {
+		return report.Extra[i].BatchNumber < report.Extra[j].BatchNumber
+	})
+
+	return report, nil
+}
@@ -0,0 +1,81 @@
+package celestiada
+
+import (
+	"context"
+	"fmt"
+)
+
+// MetadataRepair restores the metadata of a batch that was published but
+// never recorded, for example because the process crashed in between. The
+// blob at refID is fetched from the current namespace to prove it exists and
+// the metadata is rebuilt from it. If a batch deserializer is registered it
+// supplies the state root, transaction count and labels, and must decode to
+// batchNumber; otherwise those fields are left empty. Returns
+// ErrBatchAlreadyExists if the store already holds the batch.
+func (c *CDKIntegration) MetadataRepair(ctx context.Context, batchNumber uint64, refID string) error {
+	_, exists, err := c.metadataStore.Load(batchNumber)
+	if err != nil {
+		return fmt.Errorf("failed to load metadata for batch %d: %w", batchNumber, err)
+	}
+	if exists {
+		return fmt.Errorf("batch %d: %w", batchNumber, ErrBatchAlreadyExists)
+	}
+
+	height, commitment, err := parseRefID(refID)
+	if err != nil {
+		return err
+	}
+
+	namespace := c.publisher.currentNamespace()
+	b, err := c.publisher.getBlob(ctx, namespace, height, commitment, 0)
+	if err != nil {
+		return fmt.Errorf("batch %d: blob %s not found on Celestia: %w", batchNumber, refID, err)
+	}
+
+	data, compressed := c.decodeBlobData(b.Data)
+	metadata := &BatchMetadata{
+		BatchNumber:    batchNumber,
+		CelestiaHeight: height,
+		Commitment:     commitment,
+		RefID:          refID,
+		Size:           uint64(len(data)),
+		GasUsed:        estimateDataGas(b.Data),
+		DALayer:        DALayerCelestia,
+		SchemaVersion:  MetadataSchemaVersion,
+		Namespace:      c.publisher.Namespace(),
+		ShareVersion:   uint8(b.ShareVersion),
+	}
+	if compressed {
+		c.recordCompression(metadata, b.Data)
+	}
+
+	c.deserializerMu.RLock()
+	deserialize := c.deserializer
+	c.deserializerMu.RUnlock()
+	if deserialize != nil {
+		batch, err := deserialize(data)
+		if err != nil {
+			return fmt.Errorf("batch %d: failed to decode blob %s: %w", batchNumber, refID, err)
+		}
+		if batch == nil {
+			return fmt.Errorf("batch %d: blob %s is not batch data", batchNumber, refID)
+		}
+		if batch.Number != batchNumber {
+			return fmt.Errorf("blob %s holds batch %d, not batch %d", refID, batch.Number, batchNumber)
+		}
+		metadata.StateRoot = batch.StateRoot
+		metadata.TxCount = batch.TxCount
+		metadata.Labels = copyLabels(batch.Labels)
+	}
+
+	metadata.Timestamp, err = (&blockTimeCache{}).get(ctx, c.publisher, height)
+	if err != nil {
+		return err
+	}
+
+	if err := c.storeMetadata(metadata); err != nil {
+		return fmt.Errorf("failed to store metadata for batch %d: %w", batchNumber, err)
+	}
+	c.notifyMetadata(metadata)
+	return nil
+}
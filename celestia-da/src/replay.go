@@ -0,0 +1,264 @@
+package celestiada
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// BatchDeserializer decodes a blob read back from Celestia into the batch it
+// was published from. It should return an error for blobs that are not batch
+// data; ReplayFromCelestia skips those. When Config.Compression is set, blobs
+// that decompress with it are passed decompressed.
+type BatchDeserializer func([]byte) (*BatchData, error)
+
+// RegisterBatchDeserializer sets the decoder ReplayFromCelestia uses.
+func (c *CDKIntegration) RegisterBatchDeserializer(fn BatchDeserializer) {
+	c.deserializerMu.Lock()
+	defer c.deserializerMu.Unlock()
+
+	c.deserializer = fn
+}
+
+// ReplayFromCelestia rebuilds batch metadata from the blobs in the current
+// namespace at heights [fromHeight, toHeight], for recovery after the local
+// metadata store is lost. Batches already in the store are left untouched.
+// Timestamps come from block headers; the gas price paid is not recoverable
+// and is left at zero. It returns the number of batches reconstructed,
+// including those stored before an error stopped the replay.
+func (c *CDKIntegration) ReplayFromCelestia(ctx context.Context, fromHeight, toHeight uint64) (int, error) {
+	c.deserializerMu.RLock()
+	deserialize := c.deserializer
+	c.deserializerMu.RUnlock()
+	if deserialize == nil {
+		return 0, fmt.Errorf("no batch deserializer registered")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	namespace := c.publisher.Namespace()
+	results, err := c.publisher.GetNamespaceBlobs(ctx, fromHeight, toHeight)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	times := &blockTimeCache{}
+
+	for result := range results {
+		if result.Error != nil {
+			return count, result.Error
+		}
+
+		_, _, stored, err := c.replayBlob(ctx, deserialize, namespace, result, times)
+		if err != nil {
+			return count, err
+		}
+		if stored {
+			count++
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return count, err
+	}
+	return count, nil
+}
+
+// blockTimeCache remembers the time of the last header fetched, since blobs
+// arrive grouped by height.
+type blockTimeCache struct {
+	height uint64
+	time   time.Time
+}
+
+func (b *blockTimeCache) get(ctx context.Context, p batchPublisher, height uint64) (time.Time, error) {
+	if b.height != height {
+		blockTime, err := p.blockTime(ctx, height)
+		if err != nil {
+			return time.Time{}, err
+		}
+		b.height, b.time = height, blockTime
+	}
+	return b.time, nil
+}
+
+// replayBlob decodes one blob and stores metadata for it unless the batch is
+// already known. It returns a nil batch for blobs that are not batch data, and
+// stored reports whether new metadata was written.
+func (c *CDKIntegration) replayBlob(ctx context.Context, deserialize BatchDeserializer, namespace string, result NamespaceBlobResult, times *blockTimeCache) (batch *BatchData, metadata *BatchMetadata, stored bool, err error) {
+	data, compressed := c.decodeBlobData(result.Data)
+	batch, err = deserialize(data)
+	if err != nil || batch == nil {
+		return nil, nil, false, nil
+	}
+
+	existing, exists, err := c.metadataStore.Load(batch.Number)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to load metadata for batch %d: %w", batch.Number, err)
+	}
+	if exists {
+		return batch, existing, false, nil
+	}
+
+	blockTime, err := times.get(ctx, c.publisher, result.Height)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	metadata = &BatchMetadata{
+		BatchNumber:    batch.Number,
+		StateRoot:      batch.StateRoot,
+		Timestamp:      blockTime,
+		TxCount:        batch.TxCount,
+		CelestiaHeight: result.Height,
+		Commitment:     result.Commitment,
+		RefID:          fmt.Sprintf("%d:%s", result.Height, result.Commitment),
+		Labels:         copyLabels(batch.Labels),
+		Size:           uint64(len(data)),
+		GasUsed:        estimateDataGas(result.Data),
+		DALayer:        DALayerCelestia,
+		SchemaVersion:  MetadataSchemaVersion,
+		Namespace:      namespace,
+		ShareVersion:   result.ShareVersion,
+	}
+	if compressed {
+		c.recordCompression(metadata, result.Data)
+	}
+
+	c.snapshotMu.RLock()
+	err = c.metadataStore.Store(metadata)
+	c.snapshotMu.RUnlock()
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to store metadata for batch %d: %w", batch.Number, err)
+	}
+	c.updateLatest(metadata.BatchNumber)
+
+	return batch, metadata, true, nil
+}
+
+// ReplayResult is delivered by ReplaySinceHeight for each batch found. Live
+// is false while historical heights are being replayed and true once the
+// replay has caught up with the subscription. When Error is set the replay
+// has ended.
+type ReplayResult struct {
+	Height   uint64
+	Batch    *BatchData
+	Metadata *BatchMetadata
+	Live     bool
+	Error    error
+}
+
+// ReplaySinceHeight replays the namespace from fromHeight to the current
+// network head like ReplayFromCelestia, then keeps going with blobs from
+// SubscribeNamespace as they are included. The subscription is opened before
+// the head is read, so no height falls between the two phases; live heights
+// already covered by the historical pass are skipped, and gaps reported by a
+// subscription reconnect are back-filled before live delivery resumes.
+func (c *CDKIntegration) ReplaySinceHeight(ctx context.Context, fromHeight uint64) (<-chan ReplayResult, error) {
+	c.deserializerMu.RLock()
+	deserialize := c.deserializer
+	c.deserializerMu.RUnlock()
+	if deserialize == nil {
+		return nil, fmt.Errorf("no batch deserializer registered")
+	}
+	if fromHeight == 0 {
+		return nil, fmt.Errorf("invalid from height: %d", fromHeight)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	events, err := c.publisher.SubscribeNamespace(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	toHeight, err := c.publisher.networkHead(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	results := make(chan ReplayResult, 16)
+
+	go func() {
+		defer cancel()
+		defer close(results)
+
+		namespace := c.publisher.Namespace()
+		times := &blockTimeCache{}
+
+		send := func(result ReplayResult) bool {
+			select {
+			case results <- result:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		replay := func(blob NamespaceBlobResult, live bool) bool {
+			batch, metadata, _, err := c.replayBlob(ctx, deserialize, namespace, blob, times)
+			if err != nil {
+				send(ReplayResult{Height: blob.Height, Error: err})
+				return false
+			}
+			if batch == nil {
+				return true
+			}
+			return send(ReplayResult{Height: blob.Height, Batch: batch, Metadata: metadata, Live: live})
+		}
+
+		backfill := func(from, to uint64) bool {
+			if from > to {
+				return true
+			}
+			blobs, err := c.publisher.GetNamespaceBlobs(ctx, from, to)
+			if err != nil {
+				send(ReplayResult{Height: from, Error: err})
+				return false
+			}
+			for blob := range blobs {
+				if blob.Error != nil {
+					send(ReplayResult{Height: blob.Height, Error: blob.Error})
+					return false
+				}
+				if !replay(blob, false) {
+					return false
+				}
+			}
+			return ctx.Err() == nil
+		}
+
+		if !backfill(fromHeight, toHeight) {
+			return
+		}
+		lastHeight := toHeight
+
+		for event := range events {
+			if event.Error != nil {
+				send(ReplayResult{Height: event.Height, Error: event.Error})
+				return
+			}
+			// The subscription back-fills missed heights itself after a
+			// reconnect, as ordinary events following this one.
+			if event.IsReconnect {
+				continue
+			}
+			if event.Height <= lastHeight {
+				continue
+			}
+
+			for _, blob := range event.Blobs {
+				if !replay(blob, true) {
+					return
+				}
+			}
+			lastHeight = event.Height
+		}
+	}()
+
+	return results, nil
+}
@@ -0,0 +1,152 @@
+package celestiada
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+const defaultEvictionCheckInterval = 100
+
+// BatchRetentionPolicy decides which batch metadata is evicted from the store.
+// ShouldEvict is called on entries from the oldest batch number upwards and
+// the pass stops at the first entry it keeps.
+type BatchRetentionPolicy interface {
+	ShouldEvict(m *BatchMetadata) bool
+}
+
+// retentionPass is implemented by policies that need the store size before a
+// pass starts. They keep state across a pass, so each integration needs its
+// own, made with clone.
+type retentionPass interface {
+	startPass(total int)
+	clone() BatchRetentionPolicy
+}
+
+type maxAgePolicy struct {
+	maxAge time.Duration
+}
+
+// MaxAgePolicy evicts metadata for batches published more than maxAge ago.
+func MaxAgePolicy(maxAge time.Duration) BatchRetentionPolicy {
+	return maxAgePolicy{maxAge: maxAge}
+}
+
+func (p maxAgePolicy) ShouldEvict(m *BatchMetadata) bool {
+	return time.Since(m.Timestamp) > p.maxAge
+}
+
+type maxCountPolicy struct {
+	maxCount int
+	excess   int
+}
+
+// MaxCountPolicy keeps the metadata of the newest maxCount batches and evicts
+// the rest. The returned policy tracks state across a pass and must not be
+// shared between integrations; NewChildContext gives a child its own copy.
+func MaxCountPolicy(maxCount int) BatchRetentionPolicy {
+	return &maxCountPolicy{maxCount: maxCount}
+}
+
+func (p *maxCountPolicy) startPass(total int) {
+	p.excess = total - p.maxCount
+}
+
+func (p *maxCountPolicy) clone() BatchRetentionPolicy {
+	return &maxCountPolicy{maxCount: p.maxCount}
+}
+
+func (p *maxCountPolicy) ShouldEvict(*BatchMetadata) bool {
+	if p.excess <= 0 {
+		return false
+	}
+	p.excess--
+	return true
+}
+
+func (c *CDKIntegration) logger() *slog.Logger {
+	if c.config.Logger != nil {
+		return c.config.Logger
+	}
+	return slog.Default()
+}
+
+// maybeEvict starts a retention pass once Config.EvictionCheckInterval batches
+// have been processed since the last one. Like auto-compaction it runs in its
+// own goroutine so workers are not held up scanning the store.
+func (c *CDKIntegration) maybeEvict() {
+	policy := c.config.RetentionPolicy
+	if policy == nil {
+		return
+	}
+
+	interval := c.config.EvictionCheckInterval
+	if interval <= 0 {
+		interval = defaultEvictionCheckInterval
+	}
+	if c.sinceEviction.Add(1) < int64(interval) {
+		return
+	}
+	if !c.evicting.CompareAndSwap(false, true) {
+		return
+	}
+	c.sinceEviction.Store(0)
+
+	go func() {
+		defer c.evicting.Store(false)
+
+		if err := c.evictMetadata(policy); err != nil {
+			c.logger().Error("metadata eviction failed", "error", err)
+		}
+	}()
+}
+
+func (c *CDKIntegration) evictMetadata(policy BatchRetentionPolicy) error {
+	var entries []*BatchMetadata
+	err := c.metadataStore.Range(func(metadata *BatchMetadata) bool {
+		entries = append(entries, metadata)
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read metadata store: %w", err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].BatchNumber < entries[j].BatchNumber
+	})
+
+	if pass, ok := policy.(retentionPass); ok {
+		pass.startPass(len(entries))
+	}
+
+	c.snapshotMu.RLock()
+	defer c.snapshotMu.RUnlock()
+
+	evicted := make(map[uint64]bool)
+	defer func() {
+		if len(evicted) > 0 {
+			c.forgetRecent(evicted)
+		}
+	}()
+
+	for _, metadata := range entries {
+		if !policy.ShouldEvict(metadata) {
+			break
+		}
+		err := c.metadataStore.Delete(metadata.BatchNumber)
+		c.audit(c.ctx, AuditOpEvict, metadata.BatchNumber, err)
+		if err != nil {
+			return fmt.Errorf("failed to evict metadata for batch %d: %w", metadata.BatchNumber, err)
+		}
+		evicted[metadata.BatchNumber] = true
+		c.logger().Info("evicted batch metadata",
+			"batch", metadata.BatchNumber,
+			"celestiaHeight", metadata.CelestiaHeight,
+			"age", time.Since(metadata.Timestamp))
+	}
+	if len(evicted) == 0 {
+		return nil
+	}
+	return c.recomputeLatest()
+}
@@ -0,0 +1,25 @@
+package celestiada
+
+import (
+	"log/slog"
+	"time"
+)
+
+// LogRPCTracer returns a Config.RPCTracer that logs every RPC call to logger
+// at debug level.
+func LogRPCTracer(logger *slog.Logger) func(method string, duration time.Duration, err error) {
+	return func(method string, duration time.Duration, err error) {
+		if err != nil {
+			logger.Debug("celestia rpc", "method", method, "duration", duration, "error", err)
+			return
+		}
+		logger.Debug("celestia rpc", "method", method, "duration", duration)
+	}
+}
+
+// traceRPC reports a finished RPC call to Config.RPCTracer, if one is set.
+func (p *Publisher) traceRPC(method string, start time.Time, err error) {
+	if p.config.RPCTracer != nil {
+		p.config.RPCTracer(method, time.Since(start), err)
+	}
+}
@@ -0,0 +1,143 @@
+package celestiada
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// MetadataSchemaVersion is the BatchMetadata schema written by this release.
+// Records stored before versioning was introduced have SchemaVersion 0.
+// Migrations registered from this version onwards extend the schema; see
+// CurrentSchemaVersion.
+const MetadataSchemaVersion uint8 = 1
+
+type metadataMigration struct {
+	toVersion uint8
+	fn        func(*BatchMetadata) error
+}
+
+var (
+	migrationsMu sync.RWMutex
+	migrations   = map[uint8]metadataMigration{
+		0: {toVersion: 1, fn: func(*BatchMetadata) error { return nil }},
+	}
+)
+
+// RegisterMigration registers fn to upgrade metadata from fromVersion to
+// toVersion. Only one migration may start at each version, and registering a
+// second one panics, as does a migration that does not move forward. fn
+// receives a copy and may modify it in place; SchemaVersion is set to
+// toVersion after fn returns. Registering a migration from
+// CurrentSchemaVersion raises it to toVersion.
+func RegisterMigration(fromVersion, toVersion uint8, fn func(*BatchMetadata) error) {
+	if toVersion <= fromVersion {
+		panic(fmt.Sprintf("celestiada: migration must move forward: %d -> %d", fromVersion, toVersion))
+	}
+
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+
+	if existing, ok := migrations[fromVersion]; ok {
+		panic(fmt.Sprintf("celestiada: migration from schema version %d already registered (to %d)", fromVersion, existing.toVersion))
+	}
+	migrations[fromVersion] = metadataMigration{toVersion: toVersion, fn: fn}
+}
+
+// CurrentSchemaVersion returns the schema version metadata is migrated to:
+// MetadataSchemaVersion followed through every registered migration.
+func CurrentSchemaVersion() uint8 {
+	migrationsMu.RLock()
+	defer migrationsMu.RUnlock()
+
+	return currentSchemaVersion()
+}
+
+// currentSchemaVersion is CurrentSchemaVersion for callers holding
+// migrationsMu.
+func currentSchemaVersion() uint8 {
+	version := MetadataSchemaVersion
+	for {
+		m, ok := migrations[version]
+		if !ok {
+			return version
+		}
+		version = m.toVersion
+	}
+}
+
+// migrateMetadata returns metadata upgraded to CurrentSchemaVersion. The
+// original is returned unchanged if it is already current; otherwise a copy
+// is migrated so stored and cached records are never modified.
+func migrateMetadata(metadata *BatchMetadata) (*BatchMetadata, error) {
+	migrationsMu.RLock()
+	defer migrationsMu.RUnlock()
+
+	current := currentSchemaVersion()
+	if metadata.SchemaVersion == current {
+		return metadata, nil
+	}
+	if metadata.SchemaVersion > current {
+		return nil, fmt.Errorf("batch %d has schema version %d, newer than supported version %d",
+			metadata.BatchNumber, metadata.SchemaVersion, current)
+	}
+
+	migrated := *metadata
+	migrated.Labels = copyLabels(metadata.Labels)
+
+	for migrated.SchemaVersion < current {
+		m, ok := migrations[migrated.SchemaVersion]
+		if !ok {
+			return nil, fmt.Errorf("batch %d: no migration from schema version %d",
+				migrated.BatchNumber, migrated.SchemaVersion)
+		}
+		if err := m.fn(&migrated); err != nil {
+			return nil, fmt.Errorf("batch %d: migration from schema version %d to %d failed: %w",
+				migrated.BatchNumber, migrated.SchemaVersion, m.toVersion, err)
+		}
+		migrated.SchemaVersion = m.toVersion
+	}
+
+	return &migrated, nil
+}
+
+// decodeExportedMetadata decodes metadata written by ExportMetadata and
+// migrates it to the current schema version.
+func decodeExportedMetadata(data []byte) ([]*BatchMetadata, error) {
+	var entries []*BatchMetadata
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata: %w", err)
+	}
+
+	for i, metadata := range entries {
+		migrated, err := migrateMetadata(metadata)
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = migrated
+	}
+	return entries, nil
+}
+
+// ImportMetadata stores metadata in the JSON format written by
+// ExportMetadata, migrating records from older schema versions first. No
+// record is stored unless all of them migrate successfully.
+func (c *CDKIntegration) ImportMetadata(data []byte) error {
+	entries, err := decodeExportedMetadata(data)
+	if err != nil {
+		return err
+	}
+
+	c.snapshotMu.RLock()
+	defer c.snapshotMu.RUnlock()
+
+	for _, metadata := range entries {
+		err := c.metadataStore.Store(metadata)
+		c.audit(c.ctx, AuditOpImport, metadata.BatchNumber, err)
+		if err != nil {
+			return fmt.Errorf("failed to store metadata for batch %d: %w", metadata.BatchNumber, err)
+		}
+		c.updateLatest(metadata.BatchNumber)
+	}
+	return nil
+}
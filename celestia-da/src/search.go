@@ -0,0 +1,52 @@
+package celestiada
+
+import "time"
+
+// BatchMetadataQuery filters batch metadata. Zero-valued fields are unset and
+// match everything; all set fields must match.
+type BatchMetadataQuery struct {
+	MinBatchNumber uint64
+	MaxBatchNumber uint64
+	MinTimestamp   time.Time
+	MaxTimestamp   time.Time
+	MinTxCount     int
+	MaxTxCount     int
+	StateRoot      string
+}
+
+func (q BatchMetadataQuery) matches(m *BatchMetadata) bool {
+	switch {
+	case q.MinBatchNumber != 0 && m.BatchNumber < q.MinBatchNumber:
+		return false
+	case q.MaxBatchNumber != 0 && m.BatchNumber > q.MaxBatchNumber:
+		return false
+	case !q.MinTimestamp.IsZero() && m.Timestamp.Before(q.MinTimestamp):
+		return false
+	case !q.MaxTimestamp.IsZero() && m.Timestamp.After(q.MaxTimestamp):
+		return false
+	case q.MinTxCount != 0 && m.TxCount < q.MinTxCount:
+		return false
+	case q.MaxTxCount != 0 && m.TxCount > q.MaxTxCount:
+		return false
+	case q.StateRoot != "" && m.StateRoot != q.StateRoot:
+		return false
+	}
+	return true
+}
+
+// BatchMetadataSearch returns the metadata matching query in ascending batch
+// order. It scans the whole store and is meant for operator tooling.
+func (c *CDKIntegration) BatchMetadataSearch(query BatchMetadataQuery) ([]*BatchMetadata, error) {
+	entries, err := c.sortedMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*BatchMetadata
+	for _, metadata := range entries {
+		if query.matches(metadata) {
+			results = append(results, metadata)
+		}
+	}
+	return results, nil
+}
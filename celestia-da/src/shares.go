@@ -0,0 +1,89 @@
+package celestiada
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+const (
+	shareSize        = 512
+	namespaceSize    = 29
+	shareInfoBytes   = 1
+	sequenceLenBytes = 4
+	signerSize       = 20
+
+	firstSparseShareContentSize        = shareSize - namespaceSize - shareInfoBytes - sequenceLenBytes
+	continuationSparseShareContentSize = shareSize - namespaceSize - shareInfoBytes
+)
+
+// BatchDataSize reports how many shares data occupies once it is split into
+// Celestia sparse shares. Every share is shareSize bytes on the square, so
+// paddedShares*shareSize is the space the blob actually consumes.
+func BatchDataSize(data []byte, shareVersion uint8) (rawBytes int, paddedShares int, err error) {
+	rawBytes = len(data)
+	if rawBytes == 0 {
+		return 0, 0, fmt.Errorf("batch data is empty")
+	}
+
+	paddedShares, err = sparseSharesNeeded(rawBytes, shareVersion)
+	if err != nil {
+		return rawBytes, 0, err
+	}
+
+	return rawBytes, paddedShares, nil
+}
+
+func sparseSharesNeeded(size int, shareVersion uint8) (int, error) {
+	firstShare := firstSparseShareContentSize
+	switch shareVersion {
+	case share.DefaultShareVersion:
+	case 1:
+		firstShare -= signerSize
+	default:
+		return 0, fmt.Errorf("unsupported share version: %d", shareVersion)
+	}
+
+	shares := 1
+	if remaining := size - firstShare; remaining > 0 {
+		shares += (remaining + continuationSparseShareContentSize - 1) / continuationSparseShareContentSize
+	}
+	return shares, nil
+}
+
+// splitBlobShares lays data out as the sparse shares of a blob in namespace,
+// in the form they take on the data square. Only share version 0 is
+// supported, since version 1 shares also carry the signer.
+func splitBlobShares(namespace share.Namespace, data []byte, shareVersion uint8) ([][]byte, error) {
+	if shareVersion != share.DefaultShareVersion {
+		return nil, fmt.Errorf("unsupported share version: %d", shareVersion)
+	}
+	if len(namespace) != namespaceSize {
+		return nil, fmt.Errorf("invalid namespace: %d bytes, want %d", len(namespace), namespaceSize)
+	}
+
+	count, err := sparseSharesNeeded(len(data), shareVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	shares := make([][]byte, count)
+	for i := range shares {
+		sh := make([]byte, shareSize)
+		offset := copy(sh, namespace)
+
+		sh[offset] = shareVersion << 1
+		if i == 0 {
+			sh[offset] |= 1
+			binary.BigEndian.PutUint32(sh[offset+shareInfoBytes:], uint32(len(data)))
+			offset += sequenceLenBytes
+		}
+		offset += shareInfoBytes
+
+		n := copy(sh[offset:], data)
+		data = data[n:]
+		shares[i] = sh
+	}
+	return shares, nil
+}
@@ -0,0 +1,130 @@
+package celestiada
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// MetadataFlusher is implemented by metadata stores that buffer writes and
+// can force them to durable storage.
+type MetadataFlusher interface {
+	Flush() error
+}
+
+// ShutdownReport summarises a StopGracefully call. DrainedBatches were
+// published while the integration stopped, FailedBatches were attempted but
+// failed, including those cancelled in flight, and DroppedBatches were never
+// attempted.
+type ShutdownReport struct {
+	DrainedBatches int
+	FailedBatches  int
+	DroppedBatches int
+	Duration       time.Duration
+}
+
+// ErrShutdownTimeout is returned by StopGracefully when the queue did not
+// drain in time.
+type ErrShutdownTimeout struct {
+	Report ShutdownReport
+}
+
+func (e ErrShutdownTimeout) Error() string {
+	return fmt.Sprintf("shutdown timed out after %v: %d batches drained, %d failed, %d dropped",
+		e.Report.Duration, e.Report.DrainedBatches, e.Report.FailedBatches, e.Report.DroppedBatches)
+}
+
+// StopGracefully shuts the integration down. New submissions are rejected at
+// once, and queued batches get up to timeout to be published. After that,
+// in-flight submissions are cancelled and awaited, and batches still queued
+// fail with a shutdown error on their result channels; only those count as
+// dropped in the report. Batches attempted while stopping are reported as
+// drained if they were published and as failed otherwise. The metadata store
+// is then flushed if it implements MetadataFlusher, and the publisher is
+// closed, along with the store if LoadOrCreate opened it. If the timeout expired, the error is an
+// ErrShutdownTimeout carrying the report.
+func (c *CDKIntegration) StopGracefully(timeout time.Duration) error {
+	start := time.Now()
+
+	if !c.stopAccepting() {
+		return fmt.Errorf("CDK integration is already stopped")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	flushErr := c.FlushQueue(ctx)
+	cancel()
+
+	c.stopWorkers()
+
+	c.queueMu.Lock()
+	queue := c.batchQueue.Load()
+	close(queue.batches)
+	c.queueMu.Unlock()
+
+	// Workers may have taken batches off the queue after the cancellation
+	// and failed them unstarted; those count as dropped too.
+	dropped := int(c.abandoned.Load())
+	for batch := range queue.batches {
+		dropped++
+		c.removePending(batch)
+		c.donePending()
+		batch.ResultChan <- PublishResult{
+			Success: false,
+			Error:   fmt.Errorf("CDK integration is shutting down"),
+		}
+	}
+
+	var storeErr error
+	if flusher, ok := c.persistentStore().(MetadataFlusher); ok {
+		if err := flusher.Flush(); err != nil {
+			storeErr = fmt.Errorf("failed to flush metadata store: %w", err)
+		}
+	}
+
+	closeErr := c.closePublisher()
+	if err := c.closeOwnedStore(); err != nil && storeErr == nil {
+		storeErr = err
+	}
+	if err := c.closeAuditLog(); err != nil && storeErr == nil {
+		storeErr = err
+	}
+
+	report := ShutdownReport{
+		DrainedBatches: int(c.drainedOK.Load()),
+		FailedBatches:  int(c.drainFailed.Load()),
+		DroppedBatches: dropped,
+		Duration:       time.Since(start),
+	}
+
+	switch {
+	case storeErr != nil:
+		return storeErr
+	case flushErr != nil:
+		return ErrShutdownTimeout{Report: report}
+	case closeErr != nil:
+		return fmt.Errorf("failed to close publisher: %w", closeErr)
+	}
+	return nil
+}
+
+// countDrained counts result towards the ShutdownReport if the integration
+// is stopping.
+func (c *CDKIntegration) countDrained(result PublishResult) {
+	if !c.stopping.Load() {
+		return
+	}
+	if result.Success {
+		c.drainedOK.Add(1)
+	} else {
+		c.drainFailed.Add(1)
+	}
+}
+
+// stopAccepting makes later submissions fail immediately. It reports false if
+// the integration was already stopping.
+func (c *CDKIntegration) stopAccepting() bool {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+
+	return !c.stopping.Swap(true)
+}
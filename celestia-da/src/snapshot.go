@@ -0,0 +1,125 @@
+package celestiada
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// BatchMetadataSnapshot returns a deep copy of every stored batch, keyed by
+// batch number. Metadata writes are held off while the store is read, so the
+// snapshot reflects a single point in time. Once the store holds more than
+// Config.MaxSnapshotBatches entries, ErrSnapshotTooLarge is returned instead;
+// zero means no limit.
+func (c *CDKIntegration) BatchMetadataSnapshot() (map[uint64]*BatchMetadata, error) {
+	limit := c.config.MaxSnapshotBatches
+
+	c.snapshotMu.Lock()
+	defer c.snapshotMu.Unlock()
+
+	snapshot := make(map[uint64]*BatchMetadata)
+	tooLarge := false
+
+	err := c.metadataStore.Range(func(metadata *BatchMetadata) bool {
+		if limit > 0 && len(snapshot) >= limit {
+			tooLarge = true
+			return false
+		}
+
+		entry := *metadata
+		entry.Labels = copyLabels(metadata.Labels)
+		snapshot[metadata.BatchNumber] = &entry
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata store: %w", err)
+	}
+	if tooLarge {
+		return nil, fmt.Errorf("%w: more than %d batches", ErrSnapshotTooLarge, limit)
+	}
+
+	return snapshot, nil
+}
+
+type BatchMetadataDiff struct {
+	Added   []*BatchMetadata
+	Removed []*BatchMetadata
+	Changed []BatchMetadataChange
+}
+
+// BatchMetadataChange is a batch present in both snapshots with different
+// metadata. Fields lists the names of the BatchMetadata fields that differ.
+type BatchMetadataChange struct {
+	BatchNumber uint64
+	Before      *BatchMetadata
+	After       *BatchMetadata
+	Fields      []string
+}
+
+// DiffBatchMetadata compares two snapshots, such as the BatchMetadataSnapshot
+// of two nodes. Added holds batches only in b, Removed batches only in a, and
+// Changed batches in both whose fields differ. Timestamps are compared as
+// instants and nil and empty label sets are equal. Every list is sorted by
+// batch number.
+func DiffBatchMetadata(a, b map[uint64]*BatchMetadata) BatchMetadataDiff {
+	var diff BatchMetadataDiff
+
+	for batchNumber, before := range a {
+		after, ok := b[batchNumber]
+		if !ok {
+			diff.Removed = append(diff.Removed, before)
+			continue
+		}
+		if fields := changedMetadataFields(before, after); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, BatchMetadataChange{
+				BatchNumber: batchNumber,
+				Before:      before,
+				After:       after,
+				Fields:      fields,
+			})
+		}
+	}
+	for batchNumber, after := range b {
+		if _, ok := a[batchNumber]; !ok {
+			diff.Added = append(diff.Added, after)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool {
+		return diff.Added[i].BatchNumber < diff.Added[j].BatchNumber
+	})
+	sort.Slice(diff.Removed, func(i, j int) bool {
+		return diff.Removed[i].BatchNumber < diff.Removed[j].BatchNumber
+	})
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		return diff.Changed[i].BatchNumber < diff.Changed[j].BatchNumber
+	})
+
+	return diff
+}
+
+func changedMetadataFields(a, b *BatchMetadata) []string {
+	va, vb := reflect.ValueOf(*a), reflect.ValueOf(*b)
+	t := va.Type()
+
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		fa, fb := va.Field(i).Interface(), vb.Field(i).Interface()
+
+		var equal bool
+		switch x := fa.(type) {
+		case time.Time:
+			equal = x.Equal(fb.(time.Time))
+		case map[string]string:
+			y := fb.(map[string]string)
+			equal = len(x) == 0 && len(y) == 0 || reflect.DeepEqual(x, y)
+		default:
+			equal = reflect.DeepEqual(fa, fb)
+		}
+		if !equal {
+			fields = append(fields, t.Field(i).Name)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,185 @@
+package celestiada
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const latencySampleSize = 1000
+
+const (
+	completionSampleSize     = 10000
+	defaultRateWindowSeconds = 60
+)
+
+// LatencyOverflowBucket is the histogram key counting samples above the
+// largest bucket boundary.
+const LatencyOverflowBucket = time.Duration(math.MaxInt64)
+
+var DefaultLatencyBuckets = []time.Duration{
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+}
+
+type IntegrationStats struct {
+	BatchesPublished    int64
+	BatchesFailed       int64
+	FallbackBatches     int64
+	HighPriorityBatches int64
+	QueueLength         int
+	AvgSubmitLatencyMs  float64
+	P99SubmitLatencyMs  float64
+}
+
+func (c *CDKIntegration) recordResult(result PublishResult, latency time.Duration) {
+	if !result.Success {
+		c.failed.Add(1)
+		return
+	}
+	c.published.Add(1)
+	c.recordCompletion(time.Now())
+
+	c.latencyMu.Lock()
+	defer c.latencyMu.Unlock()
+
+	if len(c.latencies) < cap(c.latencies) {
+		c.latencies = append(c.latencies, latency)
+		return
+	}
+	c.latencies[c.latencyNext] = latency
+	c.latencyNext = (c.latencyNext + 1) % len(c.latencies)
+}
+
+func (c *CDKIntegration) recordCompletion(at time.Time) {
+	c.rateMu.Lock()
+	defer c.rateMu.Unlock()
+
+	if len(c.completions) < cap(c.completions) {
+		c.completions = append(c.completions, at)
+		return
+	}
+	c.completions[c.completionNext] = at
+	c.completionNext = (c.completionNext + 1) % len(c.completions)
+}
+
+// ProcessingRate returns successfully published batches per second over the
+// last Config.RateWindowSeconds seconds, 60 by default. Only the last
+// completionSampleSize completions are kept, which caps the measurable rate.
+func (c *CDKIntegration) ProcessingRate() float64 {
+	windowSeconds := c.config.RateWindowSeconds
+	if windowSeconds <= 0 {
+		windowSeconds = defaultRateWindowSeconds
+	}
+	since := time.Now().Add(-time.Duration(windowSeconds) * time.Second)
+
+	c.rateMu.RLock()
+	defer c.rateMu.RUnlock()
+
+	count := 0
+	for _, completedAt := range c.completions {
+		if completedAt.After(since) {
+			count++
+		}
+	}
+	return float64(count) / float64(windowSeconds)
+}
+
+func (c *CDKIntegration) latencySamples() []time.Duration {
+	c.latencyMu.Lock()
+	defer c.latencyMu.Unlock()
+
+	samples := make([]time.Duration, len(c.latencies))
+	copy(samples, c.latencies)
+	return samples
+}
+
+// Stats reports processing counters and submit latency over the last
+// latencySampleSize successful batches.
+func (c *CDKIntegration) Stats() IntegrationStats {
+	stats := IntegrationStats{
+		BatchesPublished:    c.published.Load(),
+		BatchesFailed:       c.failed.Load(),
+		FallbackBatches:     c.fallbacks.Load(),
+		HighPriorityBatches: c.highPriority.Load(),
+		QueueLength:         len(c.batchQueue.Load().batches),
+	}
+
+	samples := c.latencySamples()
+	if len(samples) == 0 {
+		return stats
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	var total time.Duration
+	for _, sample := range samples {
+		total += sample
+	}
+	stats.AvgSubmitLatencyMs = float64(total.Milliseconds()) / float64(len(samples))
+	stats.P99SubmitLatencyMs = float64(samples[(len(samples)*99)/100].Milliseconds())
+
+	return stats
+}
+
+// BatchLatencyHistogram counts recent submit latencies per bucket. Each sample
+// is counted under the smallest boundary it does not exceed, or under
+// LatencyOverflowBucket if it exceeds them all.
+func (c *CDKIntegration) BatchLatencyHistogram(bucketBoundaries []time.Duration) map[time.Duration]int {
+	boundaries := make([]time.Duration, len(bucketBoundaries))
+	copy(boundaries, bucketBoundaries)
+	sort.Slice(boundaries, func(i, j int) bool { return boundaries[i] < boundaries[j] })
+
+	histogram := make(map[time.Duration]int, len(boundaries)+1)
+	for _, boundary := range boundaries {
+		histogram[boundary] = 0
+	}
+
+	for _, sample := range c.latencySamples() {
+		i := sort.Search(len(boundaries), func(i int) bool { return sample <= boundaries[i] })
+		if i == len(boundaries) {
+			histogram[LatencyOverflowBucket]++
+		} else {
+			histogram[boundaries[i]]++
+		}
+	}
+
+	return histogram
+}
+
+// HistogramString renders the DefaultLatencyBuckets histogram as an ASCII
+// bar chart for log output.
+func (c *CDKIntegration) HistogramString() string {
+	const barWidth = 40
+
+	histogram := c.BatchLatencyHistogram(DefaultLatencyBuckets)
+
+	maxCount := 0
+	for _, count := range histogram {
+		if count > maxCount {
+			maxCount = count
+		}
+	}
+
+	var sb strings.Builder
+	writeRow := func(label string, count int) {
+		bar := 0
+		if maxCount > 0 {
+			bar = count * barWidth / maxCount
+		}
+		fmt.Fprintf(&sb, "%8s | %-*s %d\n", label, barWidth, strings.Repeat("#", bar), count)
+	}
+
+	for _, boundary := range DefaultLatencyBuckets {
+		writeRow("<="+boundary.String(), histogram[boundary])
+	}
+	writeRow(">"+DefaultLatencyBuckets[len(DefaultLatencyBuckets)-1].String(), histogram[LatencyOverflowBucket])
+
+	return sb.String()
+}
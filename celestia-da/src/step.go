@@ -0,0 +1,9 @@
+//go:build !testing
+
+package celestiada
+
+// batchStepper is empty outside builds with the testing tag, where
+// PauseAfterBatch and the related step-through controls are defined.
+type batchStepper struct{}
+
+func (c *CDKIntegration) afterBatch(*BatchMetadata, <-chan struct{}) {}
@@ -0,0 +1,91 @@
+//go:build testing
+
+package celestiada
+
+import "sync"
+
+// batchStepper holds a worker after a batch so tests can step through the
+// queue one batch at a time. It only exists in builds with the testing tag.
+type batchStepper struct {
+	mu        sync.Mutex
+	pause     bool
+	release   chan struct{}
+	completed chan *BatchMetadata
+}
+
+// channels creates the stepper's channels on first use. s.mu must be held.
+func (s *batchStepper) channels() {
+	if s.release == nil {
+		s.release = make(chan struct{})
+		s.completed = make(chan *BatchMetadata, 1)
+	}
+}
+
+// PauseAfterBatch makes the next worker to finish a batch hand the result to
+// WaitForBatchComplete and then wait for UnpauseAfterBatch before taking
+// another batch. The pause applies to one batch only; to step through the
+// queue, call PauseAfterBatch again before each UnpauseAfterBatch. Other
+// workers keep running, so step-through is only deterministic with a single
+// worker.
+func (c *CDKIntegration) PauseAfterBatch() {
+	c.step.mu.Lock()
+	defer c.step.mu.Unlock()
+
+	c.step.channels()
+	c.step.pause = true
+}
+
+// UnpauseAfterBatch releases the worker held by PauseAfterBatch. It does not
+// cancel a pause that has not taken effect yet.
+func (c *CDKIntegration) UnpauseAfterBatch() {
+	c.step.mu.Lock()
+	defer c.step.mu.Unlock()
+
+	c.step.channels()
+	close(c.step.release)
+	c.step.release = make(chan struct{})
+}
+
+// WaitForBatchComplete blocks until a worker pauses after a batch and returns
+// that batch's metadata. The metadata is nil if the batch failed or if the
+// integration shuts down first.
+func (c *CDKIntegration) WaitForBatchComplete() *BatchMetadata {
+	c.step.mu.Lock()
+	c.step.channels()
+	completed := c.step.completed
+	c.step.mu.Unlock()
+
+	select {
+	case metadata := <-completed:
+		return metadata
+	case <-c.ctx.Done():
+		return nil
+	}
+}
+
+// afterBatch holds the calling worker if PauseAfterBatch is in effect, until
+// UnpauseAfterBatch, the worker is stopped, or the integration shuts down.
+func (c *CDKIntegration) afterBatch(metadata *BatchMetadata, stop <-chan struct{}) {
+	c.step.mu.Lock()
+	if !c.step.pause {
+		c.step.mu.Unlock()
+		return
+	}
+	c.step.pause = false
+	release, completed := c.step.release, c.step.completed
+	c.step.mu.Unlock()
+
+	select {
+	case completed <- metadata:
+	case <-stop:
+		return
+	case <-c.ctx.Done():
+		return
+	}
+
+	select {
+	case <-release:
+	case <-stop:
+	case <-c.ctx.Done():
+	}
+}
@@ -0,0 +1,207 @@
+package celestiada
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+const (
+	defaultSubscribeReconnectDelay = time.Second
+	defaultMaxSubscribeReconnects  = 5
+)
+
+// BlobEvent is delivered by SubscribeNamespace. A reconnect event reports the
+// inclusive height range missed while the subscription was down; the blobs
+// included in that range follow it as ordinary events before live delivery
+// resumes. When Error is set the subscription has ended.
+type BlobEvent struct {
+	Height           uint64
+	Blobs            []NamespaceBlobResult
+	IsReconnect      bool
+	MissedHeightFrom uint64
+	MissedHeightTo   uint64
+	Error            error
+}
+
+// SubscribeNamespace streams new blobs in the publisher's namespace as they
+// are included. Dropped subscriptions are re-established with exponential
+// backoff starting at Config.SubscribeReconnectDelay, up to
+// Config.MaxSubscribeReconnects consecutive attempts. After a reconnect, the
+// heights after the last one delivered are back-filled up to the network
+// head, so no height is skipped or delivered twice. Back-filled heights
+// without blobs in the namespace produce no event.
+func (p *Publisher) SubscribeNamespace(ctx context.Context) (<-chan BlobEvent, error) {
+	namespace := p.currentNamespace()
+	rpcStart := time.Now()
+	sub, err := p.client.Blob.Subscribe(ctx, namespace)
+	p.traceRPC("Blob.Subscribe", rpcStart, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to namespace: %w", err)
+	}
+
+	events := make(chan BlobEvent, 16)
+	go p.runSubscription(ctx, namespace, sub, events)
+
+	return events, nil
+}
+
+func (p *Publisher) runSubscription(ctx context.Context, namespace share.Namespace, sub <-chan *blob.SubscriptionResponse, events chan<- BlobEvent) {
+	defer close(events)
+
+	send := func(event BlobEvent) bool {
+		select {
+		case events <- event:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	var lastHeight uint64
+	attempt := 0
+
+	for {
+		var resp *blob.SubscriptionResponse
+		var ok bool
+		select {
+		case resp, ok = <-sub:
+		case <-ctx.Done():
+			return
+		}
+
+		if !ok {
+			if ctx.Err() != nil {
+				return
+			}
+
+			var err error
+			sub, err = p.resubscribe(ctx, namespace, &attempt)
+			if err == nil {
+				lastHeight, err = p.backfillSubscription(ctx, namespace, lastHeight, send)
+			}
+			if err != nil {
+				send(BlobEvent{Height: lastHeight, Error: err})
+				return
+			}
+			continue
+		}
+		attempt = 0
+
+		if resp.Height <= lastHeight {
+			continue
+		}
+
+		event := BlobEvent{Height: resp.Height}
+		for _, b := range resp.Blobs {
+			event.Blobs = append(event.Blobs, NamespaceBlobResult{
+				Height:       resp.Height,
+				Commitment:   hex.EncodeToString(b.Commitment),
+				Data:         b.Data,
+				ShareVersion: uint8(b.ShareVersion),
+			})
+		}
+		if !send(event) {
+			return
+		}
+		lastHeight = resp.Height
+	}
+}
+
+// backfillSubscription runs after a reconnect. It sends the reconnect event
+// and then the blobs at every height after lastHeight up to the network head,
+// and returns the last height covered. Nothing is back-filled when no height
+// had been delivered before the subscription dropped.
+func (p *Publisher) backfillSubscription(ctx context.Context, namespace share.Namespace, lastHeight uint64, send func(BlobEvent) bool) (uint64, error) {
+	head, err := p.networkHead(ctx)
+	if err != nil {
+		return lastHeight, fmt.Errorf("failed to back-fill subscription: %w", err)
+	}
+
+	event := BlobEvent{Height: head, IsReconnect: true}
+	if lastHeight == 0 || head <= lastHeight {
+		if !send(event) {
+			return lastHeight, ctx.Err()
+		}
+		return lastHeight, nil
+	}
+	event.MissedHeightFrom = lastHeight + 1
+	event.MissedHeightTo = head
+	if !send(event) {
+		return lastHeight, ctx.Err()
+	}
+
+	for height := lastHeight + 1; height <= head; height++ {
+		rpcStart := time.Now()
+		blobs, err := p.client.Blob.GetAll(ctx, height, []share.Namespace{namespace})
+		p.traceRPC("Blob.GetAll", rpcStart, err)
+		if err != nil && !isBlobNotFound(err) {
+			return height - 1, fmt.Errorf("failed to back-fill height %d: %w", height, err)
+		}
+
+		if len(blobs) > 0 {
+			event := BlobEvent{Height: height}
+			for _, b := range blobs {
+				event.Blobs = append(event.Blobs, NamespaceBlobResult{
+					Height:       height,
+					Commitment:   hex.EncodeToString(b.Commitment),
+					Data:         b.Data,
+					ShareVersion: uint8(b.ShareVersion),
+				})
+			}
+			if !send(event) {
+				return height - 1, ctx.Err()
+			}
+		}
+	}
+	return head, nil
+}
+
+func (p *Publisher) resubscribe(ctx context.Context, namespace share.Namespace, attempt *int) (<-chan *blob.SubscriptionResponse, error) {
+	delay := p.config.SubscribeReconnectDelay
+	if delay <= 0 {
+		delay = defaultSubscribeReconnectDelay
+	}
+	maxAttempts := p.config.MaxSubscribeReconnects
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxSubscribeReconnects
+	}
+
+	for *attempt < maxAttempts {
+		select {
+		case <-time.After(delay << *attempt):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		*attempt++
+
+		rpcStart := time.Now()
+		sub, err := p.client.Blob.Subscribe(ctx, namespace)
+		p.traceRPC("Blob.Subscribe", rpcStart, err)
+		if err == nil {
+			return sub, nil
+		}
+		if !isConnectionError(err) {
+			return nil, fmt.Errorf("failed to resubscribe to namespace: %w", err)
+		}
+	}
+
+	return nil, fmt.Errorf("subscription dropped: gave up after %d reconnect attempts", maxAttempts)
+}
+
+func isConnectionError(err error) bool {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	msg := err.Error()
+	return strings.Contains(msg, "EOF") || strings.Contains(msg, "connection reset") || strings.Contains(msg, "connection refused")
+}
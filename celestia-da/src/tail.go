@@ -0,0 +1,57 @@
+package celestiada
+
+import "sort"
+
+const defaultTailBufferSize = 100
+
+func (c *CDKIntegration) recordRecent(metadata *BatchMetadata) {
+	c.tailMu.Lock()
+	defer c.tailMu.Unlock()
+
+	if len(c.recentBatches) < cap(c.recentBatches) {
+		c.recentBatches = append(c.recentBatches, metadata)
+		return
+	}
+	c.recentBatches[c.recentNext] = metadata
+	c.recentNext = (c.recentNext + 1) % len(c.recentBatches)
+}
+
+// forgetRecent drops evicted batches from the tail buffer, keeping the rest
+// in the order they were recorded.
+func (c *CDKIntegration) forgetRecent(evicted map[uint64]bool) {
+	c.tailMu.Lock()
+	defer c.tailMu.Unlock()
+
+	n := len(c.recentBatches)
+	kept := make([]*BatchMetadata, 0, cap(c.recentBatches))
+	for i := 0; i < n; i++ {
+		metadata := c.recentBatches[(c.recentNext+i)%n]
+		if !evicted[metadata.BatchNumber] {
+			kept = append(kept, metadata)
+		}
+	}
+	c.recentBatches = kept
+	c.recentNext = 0
+}
+
+// TailBatches returns up to n of the most recently published batches, highest
+// batch number first. Only the last Config.TailBufferSize batches are kept.
+func (c *CDKIntegration) TailBatches(n int) []*BatchMetadata {
+	if n <= 0 {
+		return nil
+	}
+
+	c.tailMu.RLock()
+	recent := make([]*BatchMetadata, len(c.recentBatches))
+	copy(recent, c.recentBatches)
+	c.tailMu.RUnlock()
+
+	sort.Slice(recent, func(i, j int) bool {
+		return recent[i].BatchNumber > recent[j].BatchNumber
+	})
+
+	if n < len(recent) {
+		recent = recent[:n]
+	}
+	return recent
+}
@@ -0,0 +1,69 @@
+package celestiada
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const defaultHeightCacheTTL = time.Second
+
+// GetTipHeight returns the network head height. The result is cached for
+// Config.HeightCacheTTL (one second if unset), and the cache is also fed by
+// other calls that fetch the network head anyway, such as confirmation
+// polling and health checks. Callers that need the head to be exact should
+// query the node directly.
+func (p *Publisher) GetTipHeight(ctx context.Context) (uint64, error) {
+	ttl := p.config.HeightCacheTTL
+	if ttl <= 0 {
+		ttl = defaultHeightCacheTTL
+	}
+
+	p.tipMu.Lock()
+	if !p.tipAt.IsZero() && time.Since(p.tipAt) < ttl {
+		height := p.tipHeight
+		p.tipMu.Unlock()
+		return height, nil
+	}
+	p.tipMu.Unlock()
+
+	return p.networkHead(ctx)
+}
+
+// networkHead fetches the network head height from the node, bypassing the
+// GetTipHeight cache but still feeding it.
+func (p *Publisher) networkHead(ctx context.Context) (uint64, error) {
+	rpcStart := time.Now()
+	head, err := p.client.Header.NetworkHead(ctx)
+	p.traceRPC("Header.NetworkHead", rpcStart, err)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get network head: %w", err)
+	}
+
+	height := head.Height()
+	p.recordTipHeight(height)
+	return height, nil
+}
+
+// blockTime returns the time of the block at height.
+func (p *Publisher) blockTime(ctx context.Context, height uint64) (time.Time, error) {
+	rpcStart := time.Now()
+	header, err := p.client.Header.GetByHeight(ctx, height)
+	p.traceRPC("Header.GetByHeight", rpcStart, err)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get header at height %d: %w", height, err)
+	}
+	return header.Time(), nil
+}
+
+// recordTipHeight updates the GetTipHeight cache with a freshly observed
+// network head. Heights never move backwards in the cache.
+func (p *Publisher) recordTipHeight(height uint64) {
+	p.tipMu.Lock()
+	defer p.tipMu.Unlock()
+
+	if height >= p.tipHeight {
+		p.tipHeight = height
+		p.tipAt = time.Now()
+	}
+}
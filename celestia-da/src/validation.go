@@ -0,0 +1,55 @@
+package celestiada
+
+import "fmt"
+
+// BatchValidator checks a batch before it is submitted to Celestia.
+type BatchValidator interface {
+	Validate(batch *BatchData) error
+}
+
+// ErrValidationFailed is the PublishResult error for a batch rejected by a
+// registered BatchValidator.
+type ErrValidationFailed struct {
+	Cause error
+}
+
+func (e ErrValidationFailed) Error() string {
+	return fmt.Sprintf("batch validation failed: %v", e.Cause)
+}
+
+func (e ErrValidationFailed) Unwrap() error {
+	return e.Cause
+}
+
+// RegisterBatchValidator adds a validator that runs, in registration order,
+// on every batch a worker picks up. The first error aborts the submission.
+func (c *CDKIntegration) RegisterBatchValidator(v BatchValidator) {
+	c.validatorsMu.Lock()
+	defer c.validatorsMu.Unlock()
+
+	c.validators = append(c.validators, v)
+}
+
+func (c *CDKIntegration) validateBatch(batch *BatchData) error {
+	c.validatorsMu.RLock()
+	defer c.validatorsMu.RUnlock()
+
+	for _, v := range c.validators {
+		if err := v.Validate(batch); err != nil {
+			return ErrValidationFailed{Cause: err}
+		}
+	}
+	return nil
+}
+
+// MaxTxCountValidator rejects batches with more than MaxTxCount transactions.
+type MaxTxCountValidator struct {
+	MaxTxCount int
+}
+
+func (v MaxTxCountValidator) Validate(batch *BatchData) error {
+	if batch.TxCount > v.MaxTxCount {
+		return fmt.Errorf("batch %d has %d transactions, max is %d", batch.Number, batch.TxCount, v.MaxTxCount)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package celestiada
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CheckRPCVersion fetches the node's API version and reports whether its
+// major version matches Config.MinServerVersion. Any server is compatible
+// when MinServerVersion is empty.
+func (p *Publisher) CheckRPCVersion(ctx context.Context) (serverVersion string, compatible bool, err error) {
+	rpcStart := time.Now()
+	info, err := p.client.Node.Info(ctx)
+	p.traceRPC("Node.Info", rpcStart, err)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get node info: %w", err)
+	}
+	serverVersion = info.APIVersion
+
+	if p.config.MinServerVersion == "" {
+		return serverVersion, true, nil
+	}
+
+	serverMajor, err := majorVersion(serverVersion)
+	if err != nil {
+		return serverVersion, false, fmt.Errorf("invalid server version: %w", err)
+	}
+	minMajor, err := majorVersion(p.config.MinServerVersion)
+	if err != nil {
+		return serverVersion, false, fmt.Errorf("invalid minimum server version: %w", err)
+	}
+
+	return serverVersion, serverMajor == minMajor, nil
+}
+
+func majorVersion(version string) (int, error) {
+	major, _, _ := strings.Cut(strings.TrimPrefix(version, "v"), ".")
+	n, err := strconv.Atoi(major)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse major version of %q", version)
+	}
+	return n, nil
+}